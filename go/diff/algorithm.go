@@ -0,0 +1,51 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Line-level diff algorithm.
+// Each returned Diff consists of whole lines, except the last line of a text not ending in '\n'.
+type Algorithm interface {
+	Diff(before, after string) []vscode.Diff
+}
+
+// Myers' algorithm by diffmatchpatch, which produces the minimal diff.
+type Myers struct{}
+
+// Patience diff, same as `git diff --patience`.
+// Lines unique to both sides are matched first, so that function-level changes are kept together
+// instead of being aligned to common lines like "}" and "".
+type Patience struct{}
+
+// Histogram diff, same as `git diff --histogram`.
+// An extension of patience diff, which also matches low-occurrence lines when there is no unique line.
+type Histogram struct{}
+
+func (Myers) Diff(before, after string) []vscode.Diff {
+	return myersLines(before, after)
+}
+
+func (Patience) Diff(before, after string) []vscode.Diff {
+	return patienceLines(before, after)
+}
+
+func (Histogram) Diff(before, after string) []vscode.Diff {
+	return histogramLines(before, after)
+}
+
+// Get the algorithm from its name, "myers", "patience" or "histogram", as in git's --diff-algorithm option
+func AlgorithmByName(name string) (Algorithm, error) {
+	switch name {
+	case "myers", "default":
+		return Myers{}, nil
+	case "patience":
+		return Patience{}, nil
+	case "histogram":
+		return Histogram{}, nil
+	default:
+		return nil, fmt.Errorf("unknown diff algorithm = '%s'", name)
+	}
+}
@@ -0,0 +1,281 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Same as git's histogram diff, lines occurring more than this are never used as a match anchor
+const maxChainLength = 64
+
+func toVSCodeDiffs(diffs []diffmatchpatch.Diff) []vscode.Diff {
+	converted := make([]vscode.Diff, 0, len(diffs))
+	for _, d := range diffs {
+		// vscode.DiffOperation has the same values as diffmatchpatch.Operation
+		converted = append(converted, vscode.Diff{Type: vscode.DiffOperation(d.Type), Text: d.Text})
+	}
+	return converted
+}
+
+func toDMPDiffs(diffs []vscode.Diff) []diffmatchpatch.Diff {
+	converted := make([]diffmatchpatch.Diff, 0, len(diffs))
+	for _, d := range diffs {
+		converted = append(converted, diffmatchpatch.Diff{Type: diffmatchpatch.Operation(d.Type), Text: d.Text})
+	}
+	return converted
+}
+
+// Split text into lines, each of which includes the trailing '\n'
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		// text ends in '\n', or text is empty
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func myersLines(before, after string) []vscode.Diff {
+	dmp := diffmatchpatch.New()
+	chars1, chars2, lineArray := dmp.DiffLinesToChars(before, after)
+	diffs := dmp.DiffMain(chars1, chars2, false)
+	diffs = dmp.DiffCharsToLines(diffs, lineArray)
+	return toVSCodeDiffs(diffs)
+}
+
+// Normalize diffs, so that each changed region between equalities becomes a single delete followed by a single insert
+func normalizeDiffs(diffs []vscode.Diff) []vscode.Diff {
+	var normalized []vscode.Diff
+	var equal, deleted, inserted strings.Builder
+
+	flushChange := func() {
+		if deleted.Len() > 0 {
+			normalized = append(normalized, vscode.Diff{Type: vscode.DiffDelete, Text: deleted.String()})
+			deleted.Reset()
+		}
+		if inserted.Len() > 0 {
+			normalized = append(normalized, vscode.Diff{Type: vscode.DiffInsert, Text: inserted.String()})
+			inserted.Reset()
+		}
+	}
+	flushEqual := func() {
+		if equal.Len() > 0 {
+			normalized = append(normalized, vscode.Diff{Type: vscode.DiffEqual, Text: equal.String()})
+			equal.Reset()
+		}
+	}
+
+	for _, d := range diffs {
+		switch d.Type {
+		case vscode.DiffEqual:
+			flushChange()
+			equal.WriteString(d.Text)
+		case vscode.DiffDelete:
+			flushEqual()
+			deleted.WriteString(d.Text)
+		case vscode.DiffInsert:
+			flushEqual()
+			inserted.WriteString(d.Text)
+		}
+	}
+	flushChange()
+	flushEqual()
+
+	return normalized
+}
+
+// Line-by-line differ for patience and histogram diffs, which recursively split a[aLo:aHi] and b[bLo:bHi] around matched lines
+type lineDiffer struct {
+	a     []string
+	b     []string
+	diffs []vscode.Diff
+}
+
+func (d *lineDiffer) appendLines(diffType vscode.DiffOperation, lines []string) {
+	for _, l := range lines {
+		d.diffs = append(d.diffs, vscode.Diff{Type: diffType, Text: l})
+	}
+}
+
+// Diff the range, where inner is called only when both sides are non-empty.
+// Common prefix and suffix lines are not trimmed here, but left to inner,
+// so that unique lines are anchored first, same as git.
+func (d *lineDiffer) diffRange(aLo, aHi, bLo, bHi int, inner func(aLo, aHi, bLo, bHi int)) {
+	if aLo == aHi {
+		d.appendLines(vscode.DiffInsert, d.b[bLo:bHi])
+	} else if bLo == bHi {
+		d.appendLines(vscode.DiffDelete, d.a[aLo:aHi])
+	} else {
+		inner(aLo, aHi, bLo, bHi)
+	}
+}
+
+// Diff the range by Myers' algorithm, when there is no anchor line to split the range
+func (d *lineDiffer) fallback(aLo, aHi, bLo, bHi int) {
+	before := strings.Join(d.a[aLo:aHi], "")
+	after := strings.Join(d.b[bLo:bHi], "")
+	d.diffs = append(d.diffs, myersLines(before, after)...)
+}
+
+// A pair of matched line indices, a[aIndex] == b[bIndex]
+type match struct {
+	aIndex int
+	bIndex int
+}
+
+// Longest increasing subsequence of matches ordered by aIndex, in terms of bIndex, by patience sorting
+func longestIncreasing(matches []match) []match {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// tails[k] = index in matches of the smallest tail of increasing subsequences with length k+1
+	var tails []int
+	prev := make([]int, len(matches))
+	for i, m := range matches {
+		// binary search the pile to put m on
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if matches[tails[mid]].bIndex < m.bIndex {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		} else {
+			prev[i] = -1
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+
+	result := make([]match, len(tails))
+	for i, k := len(tails)-1, tails[len(tails)-1]; i >= 0; i, k = i-1, prev[k] {
+		result[i] = matches[k]
+	}
+	return result
+}
+
+func (d *lineDiffer) patience(aLo, aHi, bLo, bHi int) {
+	d.diffRange(aLo, aHi, bLo, bHi, func(aLo, aHi, bLo, bHi int) {
+		// 1. Find lines which are unique in both sides
+		type occurrence struct {
+			aCount int
+			bCount int
+			bIndex int
+		}
+		occurrences := map[string]*occurrence{}
+		for i := aLo; i < aHi; i++ {
+			o, ok := occurrences[d.a[i]]
+			if !ok {
+				o = &occurrence{}
+				occurrences[d.a[i]] = o
+			}
+			o.aCount++
+		}
+		for i := bLo; i < bHi; i++ {
+			if o, ok := occurrences[d.b[i]]; ok {
+				o.bCount++
+				o.bIndex = i
+			}
+		}
+
+		var matches []match
+		for i := aLo; i < aHi; i++ {
+			if o := occurrences[d.a[i]]; o.aCount == 1 && o.bCount == 1 {
+				matches = append(matches, match{aIndex: i, bIndex: o.bIndex})
+			}
+		}
+
+		// 2. Anchor the longest sequence of unique lines, appearing in the same order in both sides
+		anchors := longestIncreasing(matches)
+		if len(anchors) == 0 {
+			d.fallback(aLo, aHi, bLo, bHi)
+			return
+		}
+
+		// 3. Recursively diff the ranges between anchors
+		for _, m := range anchors {
+			d.patience(aLo, m.aIndex, bLo, m.bIndex)
+			d.appendLines(vscode.DiffEqual, d.a[m.aIndex:m.aIndex+1])
+			aLo = m.aIndex + 1
+			bLo = m.bIndex + 1
+		}
+		d.patience(aLo, aHi, bLo, bHi)
+	})
+}
+
+func (d *lineDiffer) histogram(aLo, aHi, bLo, bHi int) {
+	d.diffRange(aLo, aHi, bLo, bHi, func(aLo, aHi, bLo, bHi int) {
+		// 1. Histogram of lines in a
+		occurrences := map[string][]int{}
+		for i := aLo; i < aHi; i++ {
+			occurrences[d.a[i]] = append(occurrences[d.a[i]], i)
+		}
+
+		// 2. Find the longest common region, whose lowest-occurrence line is the least frequent in a
+		bestCount := maxChainLength + 1
+		bestA, bestB, bestLen := 0, 0, 0
+		for bi := bLo; bi < bHi; bi++ {
+			occ := occurrences[d.b[bi]]
+			if len(occ) == 0 || len(occ) > maxChainLength || len(occ) > bestCount {
+				continue
+			}
+
+			for _, ai := range occ {
+				// extend the region backward and forward
+				aStart, bStart := ai, bi
+				for aStart > aLo && bStart > bLo && d.a[aStart-1] == d.b[bStart-1] {
+					aStart--
+					bStart--
+				}
+				aEnd, bEnd := ai+1, bi+1
+				for aEnd < aHi && bEnd < bHi && d.a[aEnd] == d.b[bEnd] {
+					aEnd++
+					bEnd++
+				}
+
+				count := maxChainLength + 1
+				for k := aStart; k < aEnd; k++ {
+					count = min(count, len(occurrences[d.a[k]]))
+				}
+
+				if count < bestCount || (count == bestCount && aEnd-aStart > bestLen) {
+					bestCount = count
+					bestA, bestB, bestLen = aStart, bStart, aEnd-aStart
+				}
+			}
+		}
+
+		if bestLen == 0 {
+			d.fallback(aLo, aHi, bLo, bHi)
+			return
+		}
+
+		// 3. Recursively diff the ranges before and after the region
+		d.histogram(aLo, bestA, bLo, bestB)
+		d.appendLines(vscode.DiffEqual, d.a[bestA:bestA+bestLen])
+		d.histogram(bestA+bestLen, aHi, bestB+bestLen, bHi)
+	})
+}
+
+func patienceLines(before, after string) []vscode.Diff {
+	d := lineDiffer{a: splitLines(before), b: splitLines(after)}
+	d.patience(0, len(d.a), 0, len(d.b))
+	return normalizeDiffs(d.diffs)
+}
+
+func histogramLines(before, after string) []vscode.Diff {
+	d := lineDiffer{a: splitLines(before), b: splitLines(after)}
+	d.histogram(0, len(d.a), 0, len(d.b))
+	return normalizeDiffs(d.diffs)
+}
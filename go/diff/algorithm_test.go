@@ -0,0 +1,169 @@
+package diff_test
+
+import (
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Reconstruct before and after texts from diffs
+func reconstruct(diffs []vscode.Diff) (string, string) {
+	var before, after strings.Builder
+	for _, d := range diffs {
+		if d.Type != vscode.DiffInsert {
+			before.WriteString(d.Text)
+		}
+		if d.Type != vscode.DiffDelete {
+			after.WriteString(d.Text)
+		}
+	}
+	return before.String(), after.String()
+}
+
+// Random text with lines from a small alphabet, so that many lines are repeated
+func randomLines(r *rand.Rand, n int) string {
+	alphabet := []string{"{\n", "}\n", "\n", "return nil\n", "x++\n", "if err != nil {\n", "a\n", "b\n"}
+	var builder strings.Builder
+	for i := 0; i < n; i++ {
+		builder.WriteString(alphabet[r.Intn(len(alphabet))])
+	}
+	if r.Intn(2) == 0 {
+		builder.WriteString("no newline")
+	}
+	return builder.String()
+}
+
+func TestAlgorithms(t *testing.T) {
+	beforeFile, err := os.ReadFile("testdata/patience_before.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	afterFile, err := os.ReadFile("testdata/patience_after.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := map[string]struct {
+		before string
+		after  string
+	}{
+		"both empty":     {"", ""},
+		"from empty":     {"", "a\nb\n"},
+		"to empty":       {"a\nb\n", ""},
+		"same":           {"a\nb\n", "a\nb\n"},
+		"no newline":     {"a\nb", "a\nc"},
+		"add newline":    {"a\nb", "a\nb\n"},
+		"functions":      {string(beforeFile), string(afterFile)},
+		"repeated lines": {"}\n}\n}\n", "}\nx\n}\n}\ny\n"},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		inputs["random "+string(rune('A'+i))] = struct {
+			before string
+			after  string
+		}{randomLines(r, 30), randomLines(r, 30)}
+	}
+
+	algorithms := map[string]diff.Algorithm{
+		"myers":     diff.Myers{},
+		"patience":  diff.Patience{},
+		"histogram": diff.Histogram{},
+	}
+
+	for inputName, input := range inputs {
+		for algorithmName, algorithm := range algorithms {
+			t.Run(inputName+", "+algorithmName, func(t *testing.T) {
+				diffs := algorithm.Diff(input.before, input.after)
+
+				before, after := reconstruct(diffs)
+				if input.before != before {
+					t.Errorf("before: %s", cmp.Diff(input.before, before))
+				}
+				if input.after != after {
+					t.Errorf("after: %s", cmp.Diff(input.after, after))
+				}
+
+				for _, d := range diffs {
+					if d.Text == "" {
+						t.Errorf("empty diff in %+v", diffs)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestPatienceAndHistogram(t *testing.T) {
+	before := "func a() {\n\tx := 1\n}\n\nfunc b() {\n\ty := 2\n}\n"
+	after := "func b() {\n\ty := 2\n}\n\nfunc a() {\n\tx := 1\n}\n"
+
+	// func b() is moved before func a(), and the unique lines in func b() are anchored, same as `git diff --patience`
+	expected := []vscode.Diff{
+		{Type: vscode.DiffDelete, Text: "func a() {\n\tx := 1\n}\n\n"},
+		{Type: vscode.DiffEqual, Text: "func b() {\n\ty := 2\n}\n"},
+		{Type: vscode.DiffInsert, Text: "\nfunc a() {\n\tx := 1\n}\n"},
+	}
+
+	for name, algorithm := range map[string]diff.Algorithm{"patience": diff.Patience{}, "histogram": diff.Histogram{}} {
+		t.Run(name, func(t *testing.T) {
+			result := algorithm.Diff(before, after)
+			if d := cmp.Diff(expected, result); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+}
+
+func TestAlgorithmByName(t *testing.T) {
+	cases := map[string]struct {
+		name     string
+		expected diff.Algorithm
+		err      bool
+	}{
+		"myers":         {"myers", diff.Myers{}, false},
+		"patience":      {"patience", diff.Patience{}, false},
+		"histogram":     {"histogram", diff.Histogram{}, false},
+		"ERROR unknown": {"minimal", nil, true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := diff.AlgorithmByName(c.name)
+			if err != nil {
+				if c.err {
+					return // expected error
+				}
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if c.err {
+				t.Fatalf("Expected error: but succeeded with result = %+v", result)
+			}
+			if c.expected != result {
+				t.Errorf("expected %T, but got %T", c.expected, result)
+			}
+		})
+	}
+}
+
+func TestAlgorithmWithoutLineMode(t *testing.T) {
+	opts := diff.Options{Algorithm: diff.Patience{}}
+
+	if _, err := diff.CalcEditsWithOptions("a\n", "b\n", opts); err == nil {
+		t.Errorf("Expected error: but CalcEditsWithOptions succeeded with algorithm without LineMode")
+	}
+	if _, err := diff.CalcMonacoEditsWithOptions("a\n", "b\n", opts); err == nil {
+		t.Errorf("Expected error: but CalcMonacoEditsWithOptions succeeded with algorithm without LineMode")
+	}
+
+	opts.LineMode = true
+	if _, err := diff.CalcEditsWithOptions("a\n", "b\n", opts); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
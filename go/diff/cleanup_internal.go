@@ -0,0 +1,177 @@
+package diff
+
+import (
+	"unicode/utf8"
+
+	"github.com/richardimaoka/typing-animation/go/internal/tokens"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func cleanup(diffs []diffmatchpatch.Diff, opts Options) []diffmatchpatch.Diff {
+	dmp := diffmatchpatch.New()
+
+	if opts.SemanticCleanup {
+		diffs = dmp.DiffCleanupSemantic(diffs)
+	}
+
+	if opts.EfficiencyCleanup {
+		if opts.EditCost > 0 {
+			dmp.DiffEditCost = opts.EditCost
+		}
+		diffs = dmp.DiffCleanupEfficiency(diffs)
+	}
+
+	if opts.IdentifierCleanup {
+		diffs = cleanupIdentifier(diffs)
+	}
+
+	return diffs
+}
+
+// Either an unchanged text, or a changed region with deleted and inserted texts
+type segment struct {
+	isChange bool
+	equal    string
+	deleted  string
+	inserted string
+}
+
+func toSegments(diffs []diffmatchpatch.Diff) []segment {
+	var segments []segment
+	for _, d := range diffs {
+		if d.Text == "" {
+			continue
+		}
+
+		if d.Type == diffmatchpatch.DiffEqual {
+			segments = append(segments, segment{equal: d.Text})
+			continue
+		}
+
+		if len(segments) == 0 || !segments[len(segments)-1].isChange {
+			segments = append(segments, segment{isChange: true})
+		}
+		last := &segments[len(segments)-1]
+		if d.Type == diffmatchpatch.DiffDelete {
+			last.deleted += d.Text
+		} else {
+			last.inserted += d.Text
+		}
+	}
+	return segments
+}
+
+func fromSegments(segments []segment) []diffmatchpatch.Diff {
+	var diffs []diffmatchpatch.Diff
+	for _, s := range segments {
+		if !s.isChange {
+			if s.equal != "" {
+				diffs = append(diffs, diffmatchpatch.Diff{Type: diffmatchpatch.DiffEqual, Text: s.equal})
+			}
+			continue
+		}
+
+		if s.deleted != "" {
+			diffs = append(diffs, diffmatchpatch.Diff{Type: diffmatchpatch.DiffDelete, Text: s.deleted})
+		}
+		if s.inserted != "" {
+			diffs = append(diffs, diffmatchpatch.Diff{Type: diffmatchpatch.DiffInsert, Text: s.inserted})
+		}
+	}
+	return mergeAdjacent(diffs)
+}
+
+func startsWithWord(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return tokens.IsWordRune(r)
+}
+
+func endsWithWord(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return tokens.IsWordRune(r)
+}
+
+// Byte length of the word-rune suffix in text
+func wordSuffixLen(text string) int {
+	n := 0
+	for n < len(text) {
+		r, size := utf8.DecodeLastRuneInString(text[:len(text)-n])
+		if !tokens.IsWordRune(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// Byte length of the word-rune prefix in text
+func wordPrefixLen(text string) int {
+	n := 0
+	for n < len(text) {
+		r, size := utf8.DecodeRuneInString(text[n:])
+		if !tokens.IsWordRune(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// Snap changed regions to identifier boundaries.
+// If a change starts or ends in the middle of an identifier, the rest of the identifier is moved
+// from the surrounding equality into both the deleted and inserted texts, e.g.
+//
+//	equal "new", delete "Context", insert "Ctx"  ->  delete "newContext", insert "newCtx"
+//
+// Changes which end up touching each other after snapping are merged into one.
+func cleanupIdentifier(diffs []diffmatchpatch.Diff) []diffmatchpatch.Diff {
+	segments := toSegments(diffs)
+
+	for i := range segments {
+		if !segments[i].isChange {
+			continue
+		}
+		change := &segments[i]
+
+		// Snap the start of the change
+		if i > 0 && !segments[i-1].isChange {
+			prev := &segments[i-1]
+			if endsWithWord(prev.equal) && (startsWithWord(change.deleted) || startsWithWord(change.inserted)) {
+				n := wordSuffixLen(prev.equal)
+				moved := prev.equal[len(prev.equal)-n:]
+				prev.equal = prev.equal[:len(prev.equal)-n]
+				change.deleted = moved + change.deleted
+				change.inserted = moved + change.inserted
+			}
+		}
+
+		// Snap the end of the change
+		if i < len(segments)-1 && !segments[i+1].isChange {
+			next := &segments[i+1]
+			if startsWithWord(next.equal) && (endsWithWord(change.deleted) || endsWithWord(change.inserted)) {
+				n := wordPrefixLen(next.equal)
+				moved := next.equal[:n]
+				next.equal = next.equal[n:]
+				change.deleted = change.deleted + moved
+				change.inserted = change.inserted + moved
+			}
+		}
+	}
+
+	// Merge changes separated by equalities which became empty
+	var merged []segment
+	for _, s := range segments {
+		if !s.isChange && s.equal == "" {
+			continue
+		}
+		if len(merged) > 0 && s.isChange && merged[len(merged)-1].isChange {
+			last := &merged[len(merged)-1]
+			last.deleted += s.deleted
+			last.inserted += s.inserted
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	return fromSegments(merged)
+}
@@ -0,0 +1,55 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestCleanupIdentifier(t *testing.T) {
+	del := func(text string) diffmatchpatch.Diff {
+		return diffmatchpatch.Diff{Type: diffmatchpatch.DiffDelete, Text: text}
+	}
+	ins := func(text string) diffmatchpatch.Diff {
+		return diffmatchpatch.Diff{Type: diffmatchpatch.DiffInsert, Text: text}
+	}
+	eq := func(text string) diffmatchpatch.Diff {
+		return diffmatchpatch.Diff{Type: diffmatchpatch.DiffEqual, Text: text}
+	}
+
+	cases := map[string]struct {
+		diffs    []diffmatchpatch.Diff
+		expected []diffmatchpatch.Diff
+	}{
+		"already on boundaries": {
+			[]diffmatchpatch.Diff{eq("foo("), del("bar"), ins("baz"), eq(")")},
+			[]diffmatchpatch.Diff{eq("foo("), del("bar"), ins("baz"), eq(")")},
+		},
+		"change in the middle of identifier": {
+			[]diffmatchpatch.Diff{eq("x := new"), del("Context"), ins("Ctx"), eq("(a)")},
+			[]diffmatchpatch.Diff{eq("x := "), del("newContext"), ins("newCtx"), eq("(a)")},
+		},
+		"fragmented changes in identifier": {
+			[]diffmatchpatch.Diff{eq("call(na"), del("m"), ins("M"), eq("e"), ins("s"), eq(")")},
+			[]diffmatchpatch.Diff{eq("call("), del("name"), ins("naMes"), eq(")")},
+		},
+		"insert at the end of identifier": {
+			[]diffmatchpatch.Diff{eq("return res"), ins("ult"), eq("\n")},
+			[]diffmatchpatch.Diff{eq("return "), del("res"), ins("result"), eq("\n")},
+		},
+		"insert a separate word": {
+			[]diffmatchpatch.Diff{eq("a "), ins("b "), eq("c")},
+			[]diffmatchpatch.Diff{eq("a "), ins("b "), eq("c")},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result := cleanupIdentifier(c.diffs)
+			if diff := cmp.Diff(c.expected, result); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
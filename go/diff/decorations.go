@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+)
+
+// CSS class names of the decorations, which the frontend styles
+const (
+	ClassAddedLine      = "typing-animation-added-line"
+	ClassModifiedLine   = "typing-animation-modified-line"
+	ClassDeleted        = "typing-animation-deleted"
+	ClassInserted       = "typing-animation-inserted"
+	ClassGutterAdded    = "typing-animation-gutter-added"
+	ClassGutterModified = "typing-animation-gutter-modified"
+	ClassGutterDeleted  = "typing-animation-gutter-deleted"
+)
+
+// Monaco decorations highlighting a change
+type Decorations struct {
+	// On the model before the change, e.g. the ranges about to be deleted
+	Before []monaco.ModelDeltaDecoration `json:"before"`
+	// On the model after the change, e.g. the added and modified lines
+	After []monaco.ModelDeltaDecoration `json:"after"`
+}
+
+// Decorations of the whole transition from before to after, same as an editor's diff gutter:
+// deleted ranges with gutter markers on before, and added and modified lines with gutter markers on after.
+// The diffs are calculated by opts, same as CalcMonacoEditsWithOptions, except moves and reindents.
+// As this returns no error, validate opts by CalcEditsWithOptions or CalcMonacoEditsWithOptions first.
+func CalcDecorations(before, after string, opts Options) Decorations {
+	stack := createStack(before, after, opts)
+	return decorationsFromDiffs(stack.Diffs())
+}
+
+// Decorations for each step of sequential Monaco operations, e.g. from CalcMonacoEdits,
+// so that the highlight follows the typing:
+// the range about to be deleted or replaced on the model before the step, and the inserted text on the model after the step.
+func StepDecorations(ops []monaco.SingleEditOperation) []Decorations {
+	steps := make([]Decorations, 0, len(ops))
+	for _, op := range ops {
+		step := Decorations{
+			Before: []monaco.ModelDeltaDecoration{},
+			After:  []monaco.ModelDeltaDecoration{},
+		}
+
+		if !isEmptyRange(op.Range) {
+			step.Before = append(step.Before, decoration(op.Range, ClassDeleted, false, ""))
+		}
+		if op.Text != "" {
+			start := monaco.Position{LineNumber: op.Range.StartLineNumber, Column: op.Range.StartColumn}
+			end := textEnd(start, op.Text)
+			inserted := monaco.Range{StartLineNumber: start.LineNumber, StartColumn: start.Column, EndLineNumber: end.LineNumber, EndColumn: end.Column}
+			step.After = append(step.After, decoration(inserted, ClassInserted, false, ""))
+		}
+
+		steps = append(steps, step)
+	}
+	return steps
+}
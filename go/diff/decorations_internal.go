@@ -0,0 +1,116 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Decoration described by className, or by gutterClassName for a gutter-only decoration
+func decoration(r monaco.Range, className string, isWholeLine bool, gutterClassName string) monaco.ModelDeltaDecoration {
+	description := className
+	if description == "" {
+		description = gutterClassName
+	}
+
+	return monaco.ModelDeltaDecoration{
+		Range: r,
+		Options: monaco.ModelDecorationOptions{
+			Description:               description,
+			ClassName:                 className,
+			IsWholeLine:               isWholeLine,
+			LinesDecorationsClassName: gutterClassName,
+		},
+	}
+}
+
+func lineRange(line int) monaco.Range {
+	return monaco.Range{StartLineNumber: line, StartColumn: 1, EndLineNumber: line, EndColumn: 1}
+}
+
+// How a line in after is changed
+type lineChange struct {
+	inserted bool // has inserted chars
+	kept     bool // has equal chars
+	modified bool // has a deletion inside the line
+	deleted  bool // whole lines are deleted right before the line
+}
+
+// Walk diffs with the positions in before and after, and collect the decorations
+func decorationsFromDiffs(diffs []vscode.Diff) Decorations {
+	decorations := Decorations{
+		Before: []monaco.ModelDeltaDecoration{},
+		After:  []monaco.ModelDeltaDecoration{},
+	}
+
+	beforePos := monaco.Position{LineNumber: 1, Column: 1}
+	afterPos := monaco.Position{LineNumber: 1, Column: 1}
+	changes := map[int]*lineChange{}
+	changeAt := func(line int) *lineChange {
+		if changes[line] == nil {
+			changes[line] = &lineChange{}
+		}
+		return changes[line]
+	}
+
+	for i, d := range diffs {
+		switch d.Type {
+		case vscode.DiffEqual, vscode.DiffInsert:
+			// mark each line of the text in after, where the trailing '\n' belongs to the line
+			for _, line := range strings.SplitAfter(d.Text, "\n") {
+				if line == "" {
+					continue
+				}
+				c := changeAt(afterPos.LineNumber)
+				if d.Type == vscode.DiffEqual {
+					c.kept = true
+				} else if line != "\n" || !c.kept {
+					// a new line break after the kept text only splits the line, so the line itself is unchanged
+					c.inserted = true
+				}
+				afterPos = textEnd(afterPos, line)
+			}
+			if d.Type == vscode.DiffEqual {
+				beforePos = textEnd(beforePos, d.Text)
+			}
+
+		case vscode.DiffDelete:
+			end := textEnd(beforePos, d.Text)
+			deleted := monaco.Range{StartLineNumber: beforePos.LineNumber, StartColumn: beforePos.Column, EndLineNumber: end.LineNumber, EndColumn: end.Column}
+			decorations.Before = append(decorations.Before, decoration(deleted, ClassDeleted, false, ""))
+
+			// whole lines are deleted if the deletion ends with '\n', or at the end of the text
+			wholeLines := beforePos.Column == 1 && (strings.HasSuffix(d.Text, "\n") || i == len(diffs)-1)
+			for line := beforePos.LineNumber; line <= end.LineNumber; line++ {
+				if line == end.LineNumber && end.Column == 1 {
+					break // the deletion ends at the beginning of the line
+				}
+				decorations.Before = append(decorations.Before, decoration(lineRange(line), "", false, ClassGutterDeleted))
+			}
+
+			if wholeLines {
+				changeAt(afterPos.LineNumber).deleted = true
+			} else {
+				changeAt(afterPos.LineNumber).modified = true
+			}
+			beforePos = end
+		}
+	}
+
+	for line := 1; line <= afterPos.LineNumber; line++ {
+		c := changes[line]
+		switch {
+		case c == nil:
+		case c.inserted && !c.kept && !c.modified && !c.deleted:
+			decorations.After = append(decorations.After, decoration(lineRange(line), ClassAddedLine, true, ClassGutterAdded))
+		case c.inserted || c.modified:
+			decorations.After = append(decorations.After, decoration(lineRange(line), ClassModifiedLine, true, ClassGutterModified))
+		case c.deleted:
+			// only a gutter marker, as the line itself is unchanged
+			decorations.After = append(decorations.After, decoration(lineRange(line), "", false, ClassGutterDeleted))
+		}
+	}
+
+	return decorations
+}
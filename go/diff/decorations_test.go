@@ -0,0 +1,134 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+)
+
+// Line number, classes and description of a decoration, which are easier to compare than whole decorations
+type lineDecoration struct {
+	Line        int
+	ClassName   string
+	Gutter      string
+	Description string
+}
+
+func lineDecorations(decorations []monaco.ModelDeltaDecoration) []lineDecoration {
+	lines := []lineDecoration{}
+	for _, d := range decorations {
+		lines = append(lines, lineDecoration{d.Range.StartLineNumber, d.Options.ClassName, d.Options.LinesDecorationsClassName, d.Options.Description})
+	}
+	return lines
+}
+
+func TestCalcDecorations(t *testing.T) {
+	// Description is the class name, or the gutter class name for gutter-only decorations
+	deleted := func(line int) lineDecoration { return lineDecoration{line, diff.ClassDeleted, "", diff.ClassDeleted} }
+	deletedGutter := func(line int) lineDecoration {
+		return lineDecoration{line, "", diff.ClassGutterDeleted, diff.ClassGutterDeleted}
+	}
+	added := func(line int) lineDecoration {
+		return lineDecoration{line, diff.ClassAddedLine, diff.ClassGutterAdded, diff.ClassAddedLine}
+	}
+	modified := func(line int) lineDecoration {
+		return lineDecoration{line, diff.ClassModifiedLine, diff.ClassGutterModified, diff.ClassModifiedLine}
+	}
+
+	cases := map[string]struct {
+		before         string
+		after          string
+		opts           diff.Options
+		expectedBefore []lineDecoration
+		expectedAfter  []lineDecoration
+	}{
+		"added lines": {
+			"a\nd\n",
+			"a\nb\nc\nd\n",
+			diff.Options{LineMode: true},
+			[]lineDecoration{},
+			[]lineDecoration{added(2), added(3)},
+		},
+		"deleted lines": {
+			"a\nb\nc\nd\n",
+			"a\nd\n",
+			diff.Options{LineMode: true},
+			[]lineDecoration{deleted(2), deletedGutter(2), deletedGutter(3)},
+			[]lineDecoration{deletedGutter(2)},
+		},
+		"modified line": {
+			"a\nfunc main() {}\nb\n",
+			"a\nfunc run() {}\nb\n",
+			diff.Options{LineMode: true, Refine: diff.RefineWord},
+			[]lineDecoration{deleted(2), deletedGutter(2)},
+			[]lineDecoration{modified(2)},
+		},
+		"appended line without newline": {
+			"a",
+			"a\nb",
+			diff.Options{},
+			[]lineDecoration{},
+			[]lineDecoration{added(2)},
+		},
+		"deleted last line without newline": {
+			"a\nb",
+			"a\n",
+			diff.Options{},
+			[]lineDecoration{deleted(2), deletedGutter(2)},
+			[]lineDecoration{deletedGutter(2)},
+		},
+		"no change": {
+			"a\nb\n",
+			"a\nb\n",
+			diff.Options{},
+			[]lineDecoration{},
+			[]lineDecoration{},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			decorations := diff.CalcDecorations(c.before, c.after, c.opts)
+
+			if d := cmp.Diff(c.expectedBefore, lineDecorations(decorations.Before)); d != "" {
+				t.Errorf("before: %s", d)
+			}
+			if d := cmp.Diff(c.expectedAfter, lineDecorations(decorations.After)); d != "" {
+				t.Errorf("after: %s", d)
+			}
+		})
+	}
+}
+
+func TestStepDecorations(t *testing.T) {
+	ops := []monaco.SingleEditOperation{
+		{Text: "foo\nba", Range: monaco.Range{StartLineNumber: 2, StartColumn: 3, EndLineNumber: 2, EndColumn: 3}, Operation: "Insert"},
+		{Text: "", Range: monaco.Range{StartLineNumber: 1, StartColumn: 1, EndLineNumber: 2, EndColumn: 1}, Operation: "Delete"},
+		{Text: "x", Range: monaco.Range{StartLineNumber: 1, StartColumn: 2, EndLineNumber: 1, EndColumn: 4}, Operation: "Replace"},
+	}
+
+	expected := [][2][]monaco.Range{
+		{nil, {{StartLineNumber: 2, StartColumn: 3, EndLineNumber: 3, EndColumn: 3}}},
+		{{{StartLineNumber: 1, StartColumn: 1, EndLineNumber: 2, EndColumn: 1}}, nil},
+		{{{StartLineNumber: 1, StartColumn: 2, EndLineNumber: 1, EndColumn: 4}}, {{StartLineNumber: 1, StartColumn: 2, EndLineNumber: 1, EndColumn: 3}}},
+	}
+
+	steps := diff.StepDecorations(ops)
+	if len(steps) != len(ops) {
+		t.Fatalf("expected %d steps, but got %d", len(ops), len(steps))
+	}
+	for i, step := range steps {
+		var before, after []monaco.Range
+		for _, d := range step.Before {
+			before = append(before, d.Range)
+		}
+		for _, d := range step.After {
+			after = append(after, d.Range)
+		}
+		if d := cmp.Diff(expected[i], [2][]monaco.Range{before, after}); d != "" {
+			t.Errorf("step[%d]: %s", i, d)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package diff
+
+import (
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+)
+
+// Set the cursor, selection and reveal-line hints on sequential Monaco operations, e.g. from CalcMonacoEdits,
+// so that the editor can show the cursor moving and the text being selected as if a person typed it.
+//
+//   - Insert: the cursor moves to the end of the inserted text
+//   - Delete and Replace: the range is selected first, then the cursor is at the end of the new text
+//
+// The hints assume each operation is applied after the preceding ones, so they are meaningless for MonacoBatch.
+func AddMonacoHints(ops []monaco.SingleEditOperation) []monaco.SingleEditOperation {
+	hinted := make([]monaco.SingleEditOperation, 0, len(ops))
+	for _, op := range ops {
+		op.RevealLine = op.Range.StartLineNumber
+
+		cursor := textEnd(monaco.Position{LineNumber: op.Range.StartLineNumber, Column: op.Range.StartColumn}, op.Text)
+		op.Cursor = &cursor
+
+		if isEmptyRange(op.Range) {
+			// Insert at the cursor needs forceMoveMarkers, otherwise the cursor stays before the inserted text
+			op.ForceMoveMarkers = true
+		} else {
+			selection := op.Range
+			op.Selection = &selection
+		}
+
+		hinted = append(hinted, op)
+	}
+	return hinted
+}
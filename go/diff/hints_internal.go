@@ -0,0 +1,24 @@
+package diff
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+)
+
+func isEmptyRange(r monaco.Range) bool {
+	return r.StartLineNumber == r.EndLineNumber && r.StartColumn == r.EndColumn
+}
+
+// Position at the end of text, when text is inserted at start
+func textEnd(start monaco.Position, text string) monaco.Position {
+	lastNL := strings.LastIndex(text, "\n")
+	if lastNL == -1 {
+		return monaco.Position{LineNumber: start.LineNumber, Column: start.Column + utf8.RuneCountInString(text)}
+	}
+	return monaco.Position{
+		LineNumber: start.LineNumber + strings.Count(text, "\n"),
+		Column:     1 + utf8.RuneCountInString(text[lastNL+1:]),
+	}
+}
@@ -0,0 +1,97 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestAddMonacoHints(t *testing.T) {
+	monacoRange := func(startLine, startColumn, endLine, endColumn int) monaco.Range {
+		return monaco.Range{StartLineNumber: startLine, StartColumn: startColumn, EndLineNumber: endLine, EndColumn: endColumn}
+	}
+
+	cases := map[string]struct {
+		op       monaco.SingleEditOperation
+		expected monaco.SingleEditOperation
+	}{
+		"insert": {
+			monaco.SingleEditOperation{Text: "abc", Range: monacoRange(2, 3, 2, 3), Operation: "Insert"},
+			monaco.SingleEditOperation{Text: "abc", Range: monacoRange(2, 3, 2, 3), Operation: "Insert",
+				ForceMoveMarkers: true, Cursor: &monaco.Position{LineNumber: 2, Column: 6}, RevealLine: 2},
+		},
+		"insert multi-line": {
+			monaco.SingleEditOperation{Text: "a\n\nこんにちは", Range: monacoRange(2, 3, 2, 3), Operation: "Insert"},
+			monaco.SingleEditOperation{Text: "a\n\nこんにちは", Range: monacoRange(2, 3, 2, 3), Operation: "Insert",
+				ForceMoveMarkers: true, Cursor: &monaco.Position{LineNumber: 4, Column: 6}, RevealLine: 2},
+		},
+		"delete": {
+			monaco.SingleEditOperation{Text: "", Range: monacoRange(3, 1, 5, 2), Operation: "Delete"},
+			monaco.SingleEditOperation{Text: "", Range: monacoRange(3, 1, 5, 2), Operation: "Delete",
+				Selection: &monaco.Range{StartLineNumber: 3, StartColumn: 1, EndLineNumber: 5, EndColumn: 2}, Cursor: &monaco.Position{LineNumber: 3, Column: 1}, RevealLine: 3},
+		},
+		"replace": {
+			monaco.SingleEditOperation{Text: "xy", Range: monacoRange(1, 5, 1, 8), Operation: "Replace"},
+			monaco.SingleEditOperation{Text: "xy", Range: monacoRange(1, 5, 1, 8), Operation: "Replace",
+				Selection: &monaco.Range{StartLineNumber: 1, StartColumn: 5, EndLineNumber: 1, EndColumn: 8}, Cursor: &monaco.Position{LineNumber: 1, Column: 7}, RevealLine: 1},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result := diff.AddMonacoHints([]monaco.SingleEditOperation{c.op})
+			if d := cmp.Diff([]monaco.SingleEditOperation{c.expected}, result); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+}
+
+// The cursor after each operation must be inside the model, at the end of the inserted text
+func TestMonacoHintsCursor(t *testing.T) {
+	before := "func a() {\n\treturn nil\n}\n"
+	after := "func b(x int) {\n\tif x > 0 {\n\t\treturn nil\n\t}\n}\n"
+
+	cases := map[string]diff.Options{
+		"refine char":   {LineMode: true, Refine: diff.RefineChar, MonacoHints: true},
+		"split by word": {LineMode: true, MonacoSplit: vscode.SplitByWord, MonacoHints: true},
+	}
+
+	for name, opts := range cases {
+		t.Run(name, func(t *testing.T) {
+			ops, err := diff.CalcMonacoEditsWithOptions(before, after, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			text := before
+			for i, op := range ops {
+				if op.Cursor == nil {
+					t.Fatalf("ops[%d] has no cursor, %+v", i, op)
+				}
+				if text, err = applySequential(text, ops[i:i+1]); err != nil {
+					t.Fatal(err)
+				}
+
+				m := &model{text: []rune(text)}
+				cursor, err := m.offset(op.Cursor.LineNumber, op.Cursor.Column)
+				if err != nil {
+					t.Fatalf("ops[%d] cursor is out of the model, %s", i, err)
+				}
+				start, err := m.offset(op.Range.StartLineNumber, op.Range.StartColumn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if inserted := string(m.text[start:cursor]); inserted != op.Text {
+					t.Errorf("ops[%d] expected the cursor after '%s', but after '%s'", i, op.Text, inserted)
+				}
+			}
+			if d := cmp.Diff(after, text); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+}
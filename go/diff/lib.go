@@ -8,11 +8,76 @@ import (
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
-func createStack(before, after string) *vscode.EditStack {
+type Refinement int
+
+const (
+	// Keep changed lines as they are, i.e. delete and retype whole lines
+	RefineNone Refinement = 0
+	// Re-diff changed lines word by word
+	RefineWord Refinement = 1
+	// Re-diff changed lines char by char
+	RefineChar Refinement = 2
+)
+
+type Options struct {
+	// Diff line by line first, then optionally refine only the changed lines by Refine.
+	// Otherwise, the whole text is diffed char by char, which is slow and noisy on large files.
+	LineMode bool
+	Refine   Refinement
+	// Line-level diff algorithm used in LineMode, and Myers{} is used if nil.
+	// Setting it without LineMode is an error, as the char-level diff has no algorithm to choose.
+	Algorithm Algorithm
+	// Match lines ignoring whitespace changes in LineMode, so that a reindented line is not deleted and retyped,
+	// but only its whitespace is edited
+	IgnoreWhitespace bool
+	// Detect runs of lines whose only difference is the leading whitespace,
+	// and emit them as vscode.EditReindent, which is animated as a block indent
+	Reindent bool
+
+	// Cleanups are applied in the order of the fields below, after the diff is computed.
+	//
+	// diffmatchpatch's DiffCleanupSemantic, which eliminates coincidental equalities like "e" in a renamed identifier
+	SemanticCleanup bool
+	// diffmatchpatch's DiffCleanupEfficiency, which eliminates equalities cheaper to retype than to keep.
+	// EditCost is the cost of an extra edit in chars, and diffmatchpatch's default (= 4) is used if zero.
+	EfficiencyCleanup bool
+	EditCost          int
+	// Snap edits to identifier boundaries, so that a partially changed identifier is deleted and retyped as a whole
+	IdentifierCleanup bool
+
+	// Detect blocks of at least MoveMinLines lines, deleted in one place and inserted in another,
+	// and emit them as vscode.EditMove instead of delete and retype. Zero disables move detection.
+	MoveMinLines int
+	// Minimum ratio of identical lines in a moved block, ignoring leading and trailing whitespace, and 1.0 is used if zero.
+	// Differences within a near-identical block are edited after the move.
+	MoveSimilarity float64
+
+	// Make CalcMonacoEditsWithOptions return a single batch for Monaco's executeEdits, where all the ranges are relative to before.
+	// Otherwise, the edits are sequential for animation, and each range assumes the preceding edits are applied.
+	// Moves and reindents are not detected in a batch, as the whole batch is applied at once.
+	MonacoBatch bool
+	// Split the sequential Monaco edits by the strategy, by vscode.SplitMonacoEdits, and zero keeps them as they are
+	MonacoSplit vscode.SplitStrategy
+	// Set cursor, selection and reveal-line hints on the sequential Monaco edits, by AddMonacoHints, after MonacoSplit
+	MonacoHints bool
+}
+
+// Options used by CalcEdits and CalcMonacoEdits
+func DefaultOptions() Options {
+	return Options{LineMode: false}
+}
+
+func createStack(before, after string, opts Options) *vscode.EditStack {
 	stack := vscode.NewEditStack()
-	dmp := diffmatchpatch.New()
 
-	diffs := dmp.DiffMain(before, after, true)
+	var diffs []diffmatchpatch.Diff
+	if opts.LineMode {
+		diffs = diffLineMode(before, after, opts.Algorithm, opts.Refine, opts.IgnoreWhitespace)
+	} else {
+		dmp := diffmatchpatch.New()
+		diffs = dmp.DiffMain(before, after, true)
+	}
+	diffs = cleanup(diffs, opts)
 
 	for _, d := range diffs {
 		switch d.Type {
@@ -31,23 +96,82 @@ func createStack(before, after string) *vscode.EditStack {
 }
 
 func CalcEdits(before, after string) ([]vscode.Edit, error) {
-	stack := createStack(before, after)
+	return CalcEditsWithOptions(before, after, DefaultOptions())
+}
+
+func CalcEditsWithOptions(before, after string, opts Options) ([]vscode.Edit, error) {
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("diff.CalcEdits failed, %s", err)
+	}
+
+	moves, moved := detectMoves(before, after, opts)
+	reindents, reindented := detectReindents(moved, after, opts)
+	stack := createStack(reindented, after, opts)
 
 	edits, err := stack.CalcEdits()
 	if err != nil {
 		return nil, fmt.Errorf("diff.CalcEdits failed, %s", err)
 	}
 
-	return edits, nil
+	// Moves and reindents go first, then the rest of edits are calculated against the text after them
+	result := []vscode.Edit{}
+	for _, m := range moves {
+		result = append(result, m)
+	}
+	for _, r := range reindents {
+		result = append(result, r)
+	}
+	return append(result, edits...), nil
 }
 
 func CalcMonacoEdits(before, after string) ([]monaco.SingleEditOperation, error) {
-	stack := createStack(before, after)
+	return CalcMonacoEditsWithOptions(before, after, DefaultOptions())
+}
+
+func CalcMonacoEditsWithOptions(before, after string, opts Options) ([]monaco.SingleEditOperation, error) {
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("diff.CalcMonacoEdits failed, %s", err)
+	}
+
+	if opts.MonacoBatch {
+		edits, err := createStack(before, after, opts).CalcMonacoBatchEdits()
+		if err != nil {
+			return nil, fmt.Errorf("diff.CalcMonacoEdits failed, %s", err)
+		}
+		return edits, nil
+	}
+
+	moves, moved := detectMoves(before, after, opts)
+	reindents, reindented := detectReindents(moved, after, opts)
+	stack := createStack(reindented, after, opts)
 
 	edits, err := stack.CalcMonacoEdits()
 	if err != nil {
 		return nil, fmt.Errorf("diff.CalcMonacoEdits failed, %s", err)
 	}
 
-	return edits, nil
+	// Moves and reindents go first, then the rest of edits are calculated against the text after them
+	result := []monaco.SingleEditOperation{}
+	for i, m := range moves {
+		result = append(result, m.MonacoEdits(i+1)...)
+	}
+	for _, r := range reindents {
+		reindentEdits, err := r.MonacoEdits()
+		if err != nil {
+			return nil, fmt.Errorf("diff.CalcMonacoEdits failed, %s", err)
+		}
+		result = append(result, reindentEdits...)
+	}
+	result = append(result, edits...)
+
+	if opts.MonacoSplit != 0 {
+		result, err = vscode.SplitMonacoEdits(before, result, opts.MonacoSplit)
+		if err != nil {
+			return nil, fmt.Errorf("diff.CalcMonacoEdits failed, %s", err)
+		}
+	}
+	if opts.MonacoHints {
+		result = AddMonacoHints(result)
+	}
+	return result, nil
 }
@@ -0,0 +1,145 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/internal/tokens"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Error for options which would be silently ignored
+func (opts Options) validate() error {
+	if opts.Algorithm != nil && !opts.LineMode {
+		return fmt.Errorf("algorithm = %T is only used in LineMode, but LineMode is false", opts.Algorithm)
+	}
+	return nil
+}
+
+// Line-by-line diff by algorithm, and then refine the changed lines by refine.
+// Unchanged lines are never split into char-level edits.
+func diffLineMode(before, after string, algorithm Algorithm, refine Refinement, ignoreWhitespace bool) []diffmatchpatch.Diff {
+	if ignoreWhitespace {
+		return diffIgnoringWhitespace(before, after, algorithm, refine)
+	}
+	if algorithm == nil {
+		algorithm = Myers{}
+	}
+	lineDiffs := toDMPDiffs(algorithm.Diff(before, after))
+
+	if refine == RefineNone {
+		return lineDiffs
+	}
+
+	var diffs []diffmatchpatch.Diff
+	var deleted, inserted strings.Builder
+	// Refine the pending run of deleted and inserted lines, which is a changed hunk
+	flush := func() {
+		if deleted.Len() > 0 || inserted.Len() > 0 {
+			diffs = append(diffs, refineHunk(deleted.String(), inserted.String(), refine)...)
+			deleted.Reset()
+			inserted.Reset()
+		}
+	}
+
+	for _, d := range lineDiffs {
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			deleted.WriteString(d.Text)
+		case diffmatchpatch.DiffInsert:
+			inserted.WriteString(d.Text)
+		default:
+			flush()
+			diffs = append(diffs, d)
+		}
+	}
+	flush()
+
+	return mergeAdjacent(diffs)
+}
+
+// Re-diff a changed hunk of deleted and inserted lines at finer granularity
+func refineHunk(deleted, inserted string, refine Refinement) []diffmatchpatch.Diff {
+	// Nothing to refine if the hunk is pure deletion or pure insertion
+	if deleted == "" {
+		return []diffmatchpatch.Diff{{Type: diffmatchpatch.DiffInsert, Text: inserted}}
+	} else if inserted == "" {
+		return []diffmatchpatch.Diff{{Type: diffmatchpatch.DiffDelete, Text: deleted}}
+	}
+
+	dmp := diffmatchpatch.New()
+	switch refine {
+	case RefineChar:
+		return dmp.DiffMain(deleted, inserted, false)
+	case RefineWord:
+		runes1, runes2, tokenArray := tokensToRunes(tokens.Tokenize(deleted), tokens.Tokenize(inserted))
+		diffs := dmp.DiffMainRunes(runes1, runes2, false)
+		return runesToTokens(diffs, tokenArray)
+	default:
+		return []diffmatchpatch.Diff{
+			{Type: diffmatchpatch.DiffDelete, Text: deleted},
+			{Type: diffmatchpatch.DiffInsert, Text: inserted},
+		}
+	}
+}
+
+// Same idea as diffmatchpatch's DiffLinesToRunes, but for tokens.
+// Each unique token is represented by a rune, skipping the surrogate range which is invalid in UTF-8.
+func tokensToRunes(tokens1, tokens2 []string) ([]rune, []rune, []string) {
+	var tokenArray []string
+	tokenHash := map[string]rune{}
+
+	toRunes := func(tokens []string) []rune {
+		runes := make([]rune, 0, len(tokens))
+		for _, t := range tokens {
+			r, ok := tokenHash[t]
+			if !ok {
+				r = rune(len(tokenArray))
+				if r >= 0xD800 {
+					r += 0x800
+				}
+				tokenHash[t] = r
+				tokenArray = append(tokenArray, t)
+			}
+			runes = append(runes, r)
+		}
+		return runes
+	}
+
+	// toRunes must be called before reading tokenArray, as the evaluation order in a return statement is unspecified
+	runes1 := toRunes(tokens1)
+	runes2 := toRunes(tokens2)
+	return runes1, runes2, tokenArray
+}
+
+// Inverse of tokensToRunes
+func runesToTokens(diffs []diffmatchpatch.Diff, tokenArray []string) []diffmatchpatch.Diff {
+	hydrated := make([]diffmatchpatch.Diff, 0, len(diffs))
+	for _, d := range diffs {
+		var builder strings.Builder
+		for _, r := range d.Text {
+			if r >= 0xD800+0x800 {
+				r -= 0x800
+			}
+			builder.WriteString(tokenArray[r])
+		}
+		hydrated = append(hydrated, diffmatchpatch.Diff{Type: d.Type, Text: builder.String()})
+	}
+	return hydrated
+}
+
+// Merge adjacent diffs of the same type, and drop empty diffs
+func mergeAdjacent(diffs []diffmatchpatch.Diff) []diffmatchpatch.Diff {
+	var merged []diffmatchpatch.Diff
+	for _, d := range diffs {
+		if d.Text == "" {
+			continue
+		}
+		if len(merged) > 0 && merged[len(merged)-1].Type == d.Type {
+			merged[len(merged)-1].Text += d.Text
+		} else {
+			merged = append(merged, d)
+		}
+	}
+	return merged
+}
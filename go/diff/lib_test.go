@@ -1 +1,137 @@
 package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func applyEdits(t testing.TB, before string, edits []vscode.Edit) string {
+	result := before
+	for i, e := range edits {
+		var err error
+		result, err = e.Apply(result)
+		if err != nil {
+			t.Fatalf("failed to apply edit[%d] = %+v, %s", i, e, err)
+		}
+	}
+	return result
+}
+
+// Go-like source code with `lines` lines
+func largeFile(lines int, modified bool) string {
+	var builder strings.Builder
+	for i := 0; i < lines/5; i++ {
+		fmt.Fprintf(&builder, "func function%d(a, b int) int {\n", i)
+		if modified && i%50 == 0 {
+			fmt.Fprintf(&builder, "\tresult := a*b + %d\n", i)
+		} else {
+			fmt.Fprintf(&builder, "\tresult := a + b + %d\n", i)
+		}
+		builder.WriteString("\treturn result\n")
+		builder.WriteString("}\n")
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func TestCalcEditsWithOptions(t *testing.T) {
+	inputs := map[string]struct {
+		before string
+		after  string
+	}{
+		"rename":         {"func newContext(ctx context.Context) {\n\treturn ctx\n}\n", "func newCtx(c context.Context) {\n\treturn c\n}\n"},
+		"add lines":      {"a\nb\nc\n", "a\nb\nb2\nb3\nc\n"},
+		"delete lines":   {"a\nb\nc\nd\n", "a\nd\n"},
+		"no newline":     {"a\nb", "a\nb\nc"},
+		"Japanese":       {"これは テスト です\n", "これは 本番 です\n"},
+		"large modified": {largeFile(1000, false), largeFile(1000, true)},
+	}
+
+	options := map[string]diff.Options{
+		"default":         diff.DefaultOptions(),
+		"line mode":       {LineMode: true, Refine: diff.RefineNone},
+		"line mode, word": {LineMode: true, Refine: diff.RefineWord},
+		"line mode, char": {LineMode: true, Refine: diff.RefineChar},
+		"patience, word":  {LineMode: true, Refine: diff.RefineWord, Algorithm: diff.Patience{}},
+		"histogram, char": {LineMode: true, Refine: diff.RefineChar, Algorithm: diff.Histogram{}},
+		"semantic":        {SemanticCleanup: true},
+		"efficiency":      {EfficiencyCleanup: true, EditCost: 8},
+		"identifier":      {IdentifierCleanup: true},
+		"all cleanups":    {LineMode: true, Refine: diff.RefineChar, SemanticCleanup: true, EfficiencyCleanup: true, IdentifierCleanup: true},
+	}
+
+	for inputName, input := range inputs {
+		for optName, opts := range options {
+			t.Run(inputName+", "+optName, func(t *testing.T) {
+				edits, err := diff.CalcEditsWithOptions(input.before, input.after, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				result := applyEdits(t, input.before, edits)
+				if input.after != result {
+					t.Errorf("%s", cmp.Diff(input.after, result))
+				}
+			})
+		}
+	}
+}
+
+func TestLineModeKeepsUnchangedLines(t *testing.T) {
+	before := "aaa\nbbb\nccc\n"
+	after := "aaa\nbxb\nccc\n"
+
+	edits, err := diff.CalcEditsWithOptions(before, after, diff.Options{LineMode: true, Refine: diff.RefineNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []vscode.Edit{
+		vscode.EditDelete{DeleteText: "bbb\n", DeleteRange: vscode.Range{Start: vscode.Position{Line: 1, Character: 0}, End: vscode.Position{Line: 2, Character: 0}}},
+		vscode.EditInsert{NewText: "bxb\n", Position: vscode.Position{Line: 1, Character: 0}},
+	}
+	if diff := cmp.Diff(expected, edits); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+func benchmarkCalcEdits(b *testing.B, lines int, opts diff.Options) {
+	before := largeFile(lines, false)
+	after := largeFile(lines, true)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := diff.CalcEditsWithOptions(before, after, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCharMode5000(b *testing.B) {
+	benchmarkCalcEdits(b, 5000, diff.DefaultOptions())
+}
+
+func BenchmarkLineMode5000(b *testing.B) {
+	benchmarkCalcEdits(b, 5000, diff.Options{LineMode: true, Refine: diff.RefineNone})
+}
+
+func BenchmarkLineModeWord5000(b *testing.B) {
+	benchmarkCalcEdits(b, 5000, diff.Options{LineMode: true, Refine: diff.RefineWord})
+}
+
+func BenchmarkLineModeChar5000(b *testing.B) {
+	benchmarkCalcEdits(b, 5000, diff.Options{LineMode: true, Refine: diff.RefineChar})
+}
+
+func BenchmarkCharMode20000(b *testing.B) {
+	benchmarkCalcEdits(b, 20000, diff.DefaultOptions())
+}
+
+func BenchmarkLineModeWord20000(b *testing.B) {
+	benchmarkCalcEdits(b, 20000, diff.Options{LineMode: true, Refine: diff.RefineWord})
+}
@@ -0,0 +1,174 @@
+package diff_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+)
+
+// Simulated Monaco text model, where columns count runes same as vscode.Position
+type model struct {
+	text []rune
+}
+
+// Rune offset of the one-based line number and column
+func (m *model) offset(line, column int) (int, error) {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := strings.IndexRune(string(m.text[offset:]), '\n')
+		if i < 0 {
+			return 0, fmt.Errorf("line = %d out of range", line)
+		}
+		offset += len([]rune(string(m.text[offset:])[:i])) + 1
+	}
+
+	lineEnd := len(m.text)
+	for i := offset; i < len(m.text); i++ {
+		if m.text[i] == '\n' {
+			lineEnd = i
+			break
+		}
+	}
+	if column < 1 || offset+column-1 > lineEnd {
+		return 0, fmt.Errorf("column = %d out of range on line = %d", column, line)
+	}
+	return offset + column - 1, nil
+}
+
+func (m *model) offsets(r monaco.Range) (int, int, error) {
+	start, err := m.offset(r.StartLineNumber, r.StartColumn)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := m.offset(r.EndLineNumber, r.EndColumn)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+func (m *model) replace(start, end int, text string) {
+	m.text = append(append(append([]rune{}, m.text[:start]...), []rune(text)...), m.text[end:]...)
+}
+
+// Apply operations one by one, as in the animation
+func applySequential(before string, ops []monaco.SingleEditOperation) (string, error) {
+	m := &model{text: []rune(before)}
+	for i, op := range ops {
+		start, end, err := m.offsets(op.Range)
+		if err != nil {
+			return "", fmt.Errorf("ops[%d], %s", i, err)
+		}
+		m.replace(start, end, op.Text)
+	}
+	return string(m.text), nil
+}
+
+// Apply operations as a single batch, same as Monaco's executeEdits, which rejects overlapping ranges
+func applyBatch(before string, ops []monaco.SingleEditOperation) (string, error) {
+	m := &model{text: []rune(before)}
+
+	type located struct {
+		start, end int
+		text       string
+	}
+	var sorted []located
+	for i, op := range ops {
+		start, end, err := m.offsets(op.Range)
+		if err != nil {
+			return "", fmt.Errorf("ops[%d], %s", i, err)
+		}
+		sorted = append(sorted, located{start, end, op.Text})
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i-1].end > sorted[i].start || sorted[i-1].start == sorted[i].start {
+			return "", fmt.Errorf("overlapping ranges are not allowed, %+v and %+v", sorted[i-1], sorted[i])
+		}
+	}
+
+	// From the end, so that the offsets of the preceding operations stay valid
+	for i := len(sorted) - 1; i >= 0; i-- {
+		m.replace(sorted[i].start, sorted[i].end, sorted[i].text)
+	}
+	return string(m.text), nil
+}
+
+func TestMonacoSemantics(t *testing.T) {
+	type input struct {
+		before string
+		after  string
+	}
+	inputs := map[string]input{
+		"insert and delete": {"abc\ndef\nghi\n", "abXc\nghi\nnew\n"},
+		"replace":           {"func a() {}\n", "func b() {}\n"},
+		"multi-line":        {"1\n2\n3\n4\n", "1\nx\ny\n4\n5"},
+		"Japanese":          {"こんにちは\n世界\n", "こんばんは\n世界！\n"},
+		"to empty":          {"abc\n", ""},
+		"from empty":        {"", "abc\n"},
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		inputs["random "+string(rune('A'+i))] = input{randomLines(r, 20), randomLines(r, 20)}
+	}
+
+	options := map[string]diff.Options{
+		"default":   diff.DefaultOptions(),
+		"line mode": {LineMode: true, Refine: diff.RefineWord},
+		"moves":     {LineMode: true, MoveMinLines: 2, Reindent: true},
+	}
+
+	for inputName, in := range inputs {
+		for optionName, opts := range options {
+			t.Run(inputName+", "+optionName, func(t *testing.T) {
+				// 1. Sequential
+				ops, err := diff.CalcMonacoEditsWithOptions(in.before, in.after, opts)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				result, err := applySequential(in.before, ops)
+				if err != nil {
+					t.Fatalf("sequential: %s", err)
+				}
+				if result != in.after {
+					t.Errorf("sequential: %s", cmp.Diff(in.after, result))
+				}
+
+				// 2. Batch
+				opts.MonacoBatch = true
+				ops, err = diff.CalcMonacoEditsWithOptions(in.before, in.after, opts)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				result, err = applyBatch(in.before, ops)
+				if err != nil {
+					t.Fatalf("batch: %s", err)
+				}
+				if result != in.after {
+					t.Errorf("batch: %s", cmp.Diff(in.after, result))
+				}
+			})
+		}
+	}
+}
+
+func TestMonacoBatchReplace(t *testing.T) {
+	ops, err := diff.CalcMonacoEditsWithOptions("abc def\nghi", "abc xyz\nghi", diff.Options{MonacoBatch: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []monaco.SingleEditOperation{
+		{Text: "xyz", Range: monaco.Range{StartLineNumber: 1, StartColumn: 5, EndLineNumber: 1, EndColumn: 8}, Operation: "Replace"},
+	}
+	if d := cmp.Diff(expected, ops); d != "" {
+		t.Errorf("%s", d)
+	}
+}
@@ -0,0 +1,243 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Number of consecutive dissimilar lines allowed in a near-identical moved block
+const maxMoveGap = 2
+
+// Block of lines deleted at before[aStart:aStart+length], and inserted at after[bStart:bStart+length]
+type moveBlock struct {
+	aStart int
+	bStart int
+	length int
+	// Index of the line in before, *before which* the block is inserted
+	insertAt int
+}
+
+// Line-level view of a diff between before and after, to find moved blocks
+type moveDetector struct {
+	a          []string
+	b          []string
+	deleted    []bool // deleted[i] = whether a[i] is deleted
+	inserted   []bool // inserted[j] = whether b[j] is inserted
+	insertAt   []int  // insertAt[j] = index of the line in a, before which b[j] is inserted
+	usedA      []bool
+	usedB      []bool
+	similarity float64
+}
+
+func newMoveDetector(before, after string, algorithm Algorithm, similarity float64) *moveDetector {
+	if algorithm == nil {
+		algorithm = Myers{}
+	}
+	if similarity <= 0 {
+		similarity = 1.0
+	}
+
+	m := &moveDetector{a: splitLines(before), b: splitLines(after), similarity: similarity}
+	m.deleted = make([]bool, len(m.a))
+	m.usedA = make([]bool, len(m.a))
+	m.inserted = make([]bool, len(m.b))
+	m.insertAt = make([]int, len(m.b))
+	m.usedB = make([]bool, len(m.b))
+
+	ai, bi := 0, 0
+	for _, d := range algorithm.Diff(before, after) {
+		n := len(splitLines(d.Text))
+		switch d.Type {
+		case vscode.DiffEqual:
+			ai += n
+			bi += n
+		case vscode.DiffDelete:
+			for k := 0; k < n; k++ {
+				m.deleted[ai+k] = true
+			}
+			ai += n
+		case vscode.DiffInsert:
+			for k := 0; k < n; k++ {
+				m.inserted[bi+k] = true
+				m.insertAt[bi+k] = ai
+			}
+			bi += n
+		}
+	}
+
+	return m
+}
+
+func (m *moveDetector) similar(i, j int) bool {
+	return strings.TrimSpace(m.a[i]) == strings.TrimSpace(m.b[j])
+}
+
+// Only whole lines ending in '\n' can be moved, so that the cut and paste never join lines
+func (m *moveDetector) movableA(i int) bool {
+	return i < len(m.a) && m.deleted[i] && !m.usedA[i] && strings.HasSuffix(m.a[i], "\n")
+}
+
+func (m *moveDetector) movableB(j, insertAt int) bool {
+	return j < len(m.b) && m.inserted[j] && !m.usedB[j] && m.insertAt[j] == insertAt && strings.HasSuffix(m.b[j], "\n")
+}
+
+// Extend the block from a[i] and b[j] forward, skipping up to maxMoveGap consecutive dissimilar lines,
+// as long as the ratio of similar lines is above the threshold.
+// The block always ends in a similar line.
+func (m *moveDetector) extend(i, j int) moveBlock {
+	block := moveBlock{aStart: i, bStart: j, insertAt: m.insertAt[j]}
+
+	matched, gap := 0, 0
+	for k := 0; m.movableA(i+k) && m.movableB(j+k, block.insertAt); k++ {
+		if m.similar(i+k, j+k) {
+			matched++
+			gap = 0
+			if float64(matched)/float64(k+1) >= m.similarity {
+				block.length = k + 1
+			}
+		} else {
+			gap++
+			if m.similarity >= 1.0 || gap > maxMoveGap {
+				break
+			}
+		}
+	}
+
+	return block
+}
+
+// Whether the block is really moved, rather than deleted and inserted at the same place,
+// or moved only across deleted lines
+func (m *moveDetector) isMove(block moveBlock) bool {
+	aEnd := block.aStart + block.length
+	if block.aStart <= block.insertAt && block.insertAt <= aEnd {
+		return false
+	}
+
+	lo, hi := aEnd, block.insertAt
+	if block.insertAt < block.aStart {
+		lo, hi = block.insertAt, block.aStart
+	}
+	for i := lo; i < hi; i++ {
+		if !m.deleted[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Find the longest moved block among the lines not used yet
+func (m *moveDetector) longest(minLines int) (moveBlock, bool) {
+	// after lines indexed by trimmed text, as seeds of blocks
+	seeds := map[string][]int{}
+	for j := range m.b {
+		if key := strings.TrimSpace(m.b[j]); key != "" && m.movableB(j, m.insertAt[j]) {
+			seeds[key] = append(seeds[key], j)
+		}
+	}
+
+	var best moveBlock
+	for i := range m.a {
+		if !m.movableA(i) {
+			continue
+		}
+		for _, j := range seeds[strings.TrimSpace(m.a[i])] {
+			block := m.extend(i, j)
+			if block.length > best.length && block.length >= minLines && m.isMove(block) {
+				best = block
+			}
+		}
+	}
+
+	return best, best.length > 0
+}
+
+// Detect moved blocks, and return them as EditMove, as well as the text after applying the moves to before.
+// The moves are applied in the order of their positions in after.
+func detectMoves(before, after string, opts Options) ([]vscode.EditMove, string) {
+	if opts.MoveMinLines <= 0 {
+		return nil, before
+	}
+
+	m := newMoveDetector(before, after, opts.Algorithm, opts.MoveSimilarity)
+
+	// 1. Pick the longest blocks first
+	var blocks []moveBlock
+	for {
+		block, found := m.longest(opts.MoveMinLines)
+		if !found {
+			break
+		}
+		for k := 0; k < block.length; k++ {
+			m.usedA[block.aStart+k] = true
+			m.usedB[block.bStart+k] = true
+		}
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(x, y int) bool { return blocks[x].bStart < blocks[y].bStart })
+
+	// 2. Apply the moves to lines, each of which is tagged with its original index in before
+	type taggedLine struct {
+		text   string
+		origin int
+	}
+	current := make([]taggedLine, len(m.a))
+	for i, l := range m.a {
+		current[i] = taggedLine{text: l, origin: i}
+	}
+	indexOf := func(origin int) int {
+		for i, l := range current {
+			if l.origin == origin {
+				return i
+			}
+		}
+		return len(current)
+	}
+
+	var moves []vscode.EditMove
+	for _, block := range blocks {
+		// 2.1. Cut, only if the block is still contiguous, i.e. not split by an earlier move
+		start := indexOf(block.aStart)
+		contiguous := start+block.length <= len(current)
+		for k := 0; contiguous && k < block.length; k++ {
+			contiguous = current[start+k].origin == block.aStart+k
+		}
+		if !contiguous {
+			continue // leave it to the normal diff
+		}
+		cut := append([]taggedLine{}, current[start:start+block.length]...)
+		rest := append(append([]taggedLine{}, current[:start]...), current[start+block.length:]...)
+
+		// 2.2. Paste
+		dst := len(rest)
+		for i, l := range rest {
+			if l.origin == block.insertAt {
+				dst = i
+				break
+			}
+		}
+		if dst == len(rest) && dst > 0 && !strings.HasSuffix(rest[dst-1].text, "\n") {
+			continue // cannot paste after the last line without '\n'
+		}
+		current = append(append(rest[:dst:dst], cut...), rest[dst:]...)
+
+		var moveText strings.Builder
+		for _, l := range cut {
+			moveText.WriteString(l.text)
+		}
+		moves = append(moves, vscode.EditMove{
+			MoveText:   moveText.String(),
+			FromRange:  vscode.Range{Start: vscode.Position{Line: start, Character: 0}, End: vscode.Position{Line: start + block.length, Character: 0}},
+			ToPosition: vscode.Position{Line: dst, Character: 0},
+		})
+	}
+
+	var moved strings.Builder
+	for _, l := range current {
+		moved.WriteString(l.text)
+	}
+
+	return moves, moved.String()
+}
@@ -0,0 +1,101 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestMoveDetection(t *testing.T) {
+	funcA := "func a() {\n\tx := 1\n\treturn x\n}\n"
+	funcB := "func b() {\n\ty := 2\n\treturn y\n}\n"
+	funcC := "func c() {\n\tz := 3\n\treturn z\n}\n"
+	funcBModified := "func b() {\n\ty := 20\n\treturn y\n}\n"
+
+	cases := map[string]struct {
+		before string
+		after  string
+		opts   diff.Options
+		moves  int
+	}{
+		"move function down": {
+			funcA + "\n" + funcB + "\n" + funcC,
+			funcB + "\n" + funcC + "\n" + funcA,
+			diff.Options{LineMode: true, MoveMinLines: 3},
+			1,
+		},
+		"move function up": {
+			funcA + "\n" + funcB + "\n" + funcC,
+			funcC + "\n" + funcA + "\n" + funcB,
+			diff.Options{LineMode: true, MoveMinLines: 3},
+			1,
+		},
+		"near-identical move": {
+			funcA + "\n" + funcB + "\n" + funcC,
+			funcBModified + "\n" + funcA + "\n" + funcC,
+			diff.Options{LineMode: true, Refine: diff.RefineChar, MoveMinLines: 3, MoveSimilarity: 0.6},
+			1,
+		},
+		"near-identical move, exact required": {
+			funcA + "\n" + funcB + "\n" + funcC,
+			funcBModified + "\n" + funcA + "\n" + funcC,
+			diff.Options{LineMode: true, MoveMinLines: 3},
+			0,
+		},
+		"block too short": {
+			funcA + "\n" + funcB + "\n" + funcC,
+			funcB + "\n" + funcC + "\n" + funcA,
+			diff.Options{LineMode: true, MoveMinLines: 10},
+			0,
+		},
+		"no newline at end": {
+			funcA + "\n" + funcB + "\nlast",
+			funcB + "\n" + funcA + "\nlast",
+			diff.Options{MoveMinLines: 3},
+			1,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			edits, err := diff.CalcEditsWithOptions(c.before, c.after, c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			moves := 0
+			for _, e := range edits {
+				if _, ok := e.(vscode.EditMove); ok {
+					moves++
+				}
+			}
+			if c.moves != moves {
+				t.Errorf("expected %d moves, but got %d in %+v", c.moves, moves, edits)
+			}
+
+			result := applyEdits(t, c.before, edits)
+			if c.after != result {
+				t.Errorf("%s", cmp.Diff(c.after, result))
+			}
+		})
+	}
+}
+
+func TestMoveMonacoEdits(t *testing.T) {
+	before := "a1\na2\na3\n\nb1\nb2\nb3\n"
+	after := "b1\nb2\nb3\n\na1\na2\na3\n"
+
+	edits, err := diff.CalcMonacoEditsWithOptions(before, after, diff.Options{LineMode: true, MoveMinLines: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(edits) < 2 {
+		t.Fatalf("expected a pair of move operations, but got %+v", edits)
+	}
+	if edits[0].MoveID == 0 || edits[0].MoveID != edits[1].MoveID || edits[0].Operation != "Delete" || edits[1].Operation != "Insert" {
+		t.Errorf("expected a pair of Delete and Insert with the same MoveID, but got %+v", edits[:2])
+	}
+}
@@ -0,0 +1,127 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Normalize a line for whitespace-insensitive comparison.
+// Leading and trailing whitespace is removed, and runs of whitespace in the middle are collapsed into a single space.
+func normalizeWhitespace(line string) string {
+	body, hasNewline := strings.CutSuffix(line, "\n")
+	normalized := strings.Join(strings.Fields(body), " ")
+	if hasNewline {
+		return normalized + "\n"
+	}
+	// The last line without '\n' must not vanish even if it is whitespace-only, to keep the number of lines
+	return normalized + "\x00"
+}
+
+// Split line into the leading whitespace and the rest
+func splitIndent(line string) (string, string) {
+	body := strings.TrimLeft(line, " \t")
+	return line[:len(line)-len(body)], body
+}
+
+// Match lines in a and b, which are equal ignoring whitespace, by the line-level algorithm
+func matchIgnoringWhitespace(a, b []string, algorithm Algorithm) []match {
+	if algorithm == nil {
+		algorithm = Myers{}
+	}
+
+	normalize := func(lines []string) string {
+		var builder strings.Builder
+		for _, l := range lines {
+			builder.WriteString(normalizeWhitespace(l))
+		}
+		return builder.String()
+	}
+
+	var matches []match
+	ai, bi := 0, 0
+	for _, d := range algorithm.Diff(normalize(a), normalize(b)) {
+		n := len(splitLines(d.Text))
+		switch d.Type {
+		case vscode.DiffEqual:
+			for k := 0; k < n; k++ {
+				matches = append(matches, match{aIndex: ai + k, bIndex: bi + k})
+			}
+			ai += n
+			bi += n
+		case vscode.DiffDelete:
+			ai += n
+		case vscode.DiffInsert:
+			bi += n
+		}
+	}
+
+	return matches
+}
+
+// Line-by-line diff ignoring whitespace, and then refine the changed lines by refine.
+// Lines differing only in whitespace are diffed char by char, so that only the whitespace is edited.
+func diffIgnoringWhitespace(before, after string, algorithm Algorithm, refine Refinement) []diffmatchpatch.Diff {
+	a, b := splitLines(before), splitLines(after)
+	dmp := diffmatchpatch.New()
+
+	var diffs []diffmatchpatch.Diff
+	ai, bi := 0, 0
+	// Refine the changed hunk between the previous match and the next match
+	flush := func(aEnd, bEnd int) {
+		deleted := strings.Join(a[ai:aEnd], "")
+		inserted := strings.Join(b[bi:bEnd], "")
+		if deleted != "" || inserted != "" {
+			diffs = append(diffs, refineHunk(deleted, inserted, refine)...)
+		}
+	}
+
+	for _, m := range matchIgnoringWhitespace(a, b, algorithm) {
+		flush(m.aIndex, m.bIndex)
+		if a[m.aIndex] == b[m.bIndex] {
+			diffs = append(diffs, diffmatchpatch.Diff{Type: diffmatchpatch.DiffEqual, Text: a[m.aIndex]})
+		} else {
+			diffs = append(diffs, dmp.DiffMain(a[m.aIndex], b[m.bIndex], false)...)
+		}
+		ai, bi = m.aIndex+1, m.bIndex+1
+	}
+	flush(len(a), len(b))
+
+	return mergeAdjacent(diffs)
+}
+
+// Detect runs of lines whose only difference is the leading whitespace, and return them as EditReindent,
+// as well as the text after applying the reindents to before.
+func detectReindents(before, after string, opts Options) ([]vscode.EditReindent, string) {
+	if !opts.Reindent {
+		return nil, before
+	}
+
+	a, b := splitLines(before), splitLines(after)
+	reindented := append([]string{}, a...)
+
+	var reindents []vscode.EditReindent
+	for _, m := range matchIgnoringWhitespace(a, b, opts.Algorithm) {
+		oldIndent, oldBody := splitIndent(a[m.aIndex])
+		newIndent, newBody := splitIndent(b[m.bIndex])
+		if oldIndent == newIndent || oldBody != newBody {
+			continue // not a pure reindent, left to the normal diff
+		}
+		reindented[m.aIndex] = newIndent + oldBody
+
+		// Extend the last reindent if the line is next to it, as reindents never change line numbers
+		if n := len(reindents); n > 0 && reindents[n-1].StartLine+len(reindents[n-1].OldIndents) == m.aIndex {
+			reindents[n-1].OldIndents = append(reindents[n-1].OldIndents, oldIndent)
+			reindents[n-1].NewIndents = append(reindents[n-1].NewIndents, newIndent)
+		} else {
+			reindents = append(reindents, vscode.EditReindent{
+				StartLine:  m.aIndex,
+				OldIndents: []string{oldIndent},
+				NewIndents: []string{newIndent},
+			})
+		}
+	}
+
+	return reindents, strings.Join(reindented, "")
+}
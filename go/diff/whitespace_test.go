@@ -0,0 +1,116 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestWhitespaceOptions(t *testing.T) {
+	body := "x := 1\ny := 2\nreturn x + y\n"
+	wrapped := "if ok {\n\tx := 1\n\ty := 2\n\treturn x + y\n}\n"
+
+	cases := map[string]struct {
+		before    string
+		after     string
+		opts      diff.Options
+		reindents int
+		deletes   int
+	}{
+		"wrap in if block, reindent": {
+			body, wrapped,
+			diff.Options{LineMode: true, Reindent: true},
+			1, 0,
+		},
+		"wrap in if block, ignore whitespace": {
+			body, wrapped,
+			diff.Options{LineMode: true, IgnoreWhitespace: true},
+			0, 0,
+		},
+		"wrap in if block, no options": {
+			body, wrapped,
+			diff.Options{LineMode: true},
+			0, 1,
+		},
+		"unwrap if block, reindent": {
+			wrapped, body,
+			diff.Options{LineMode: true, Reindent: true},
+			1, 2,
+		},
+		"tabs to spaces, reindent": {
+			"func f() {\n\tx()\n\ty()\n}\n", "func f() {\n    x()\n    y()\n}\n",
+			diff.Options{LineMode: true, Reindent: true},
+			1, 0,
+		},
+		"blank line splits reindents": {
+			"a\n\nb\n", "\ta\n\n\tb\n",
+			diff.Options{LineMode: true, Reindent: true},
+			2, 0,
+		},
+		"reindent and change": {
+			"a\nb\n", "  a\n  c\n",
+			diff.Options{LineMode: true, Reindent: true},
+			1, 1,
+		},
+		"inner whitespace, ignore whitespace": {
+			"x  =  1\n", "x = 1\n",
+			diff.Options{LineMode: true, IgnoreWhitespace: true, Refine: diff.RefineWord},
+			0, 2,
+		},
+		"no newline at end, reindent": {
+			"a\nb", "  a\n  b",
+			diff.Options{Reindent: true},
+			1, 0,
+		},
+		"whitespace-only last line, ignore whitespace": {
+			"a\n  ", "a\n\t",
+			diff.Options{LineMode: true, IgnoreWhitespace: true},
+			0, 1,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			edits, err := diff.CalcEditsWithOptions(c.before, c.after, c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reindents, deletes := 0, 0
+			for _, e := range edits {
+				switch e.(type) {
+				case vscode.EditReindent:
+					reindents++
+				case vscode.EditDelete:
+					deletes++
+				}
+			}
+			if c.reindents != reindents || c.deletes != deletes {
+				t.Errorf("expected %d reindents and %d deletes, but got %d and %d in %+v", c.reindents, c.deletes, reindents, deletes, edits)
+			}
+
+			result := applyEdits(t, c.before, edits)
+			if c.after != result {
+				t.Errorf("%s", cmp.Diff(c.after, result))
+			}
+		})
+	}
+}
+
+func TestReindentMonacoEdits(t *testing.T) {
+	edits, err := diff.CalcMonacoEditsWithOptions("a\n\tb\n", "\ta\n\t\tb\n", diff.Options{LineMode: true, Reindent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []monaco.SingleEditOperation{
+		{Text: "\t", Range: monaco.Range{StartColumn: 1, StartLineNumber: 1, EndColumn: 1, EndLineNumber: 1}, Operation: "Reindent"},
+		{Text: "\t\t", Range: monaco.Range{StartColumn: 1, StartLineNumber: 2, EndColumn: 2, EndLineNumber: 2}, Operation: "Reindent"},
+	}
+	if d := cmp.Diff(expected, edits); d != "" {
+		t.Errorf("%s", d)
+	}
+}
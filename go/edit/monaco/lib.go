@@ -8,9 +8,44 @@ type Range struct {
 	EndLineNumber   int `json:"endLineNumber"`
 }
 
+// https://microsoft.github.io/monaco-editor/docs.html#interfaces/IPosition.html
+type Position struct {
+	LineNumber int `json:"lineNumber"`
+	Column     int `json:"column"`
+}
+
 // https://microsoft.github.io/monaco-editor/docs.html#interfaces/editor.ISingleEditOperation.html#range
 type SingleEditOperation struct {
 	Text      string `json:"text"`
 	Range     Range  `json:"range"`
 	Operation string `json:"operation"`
+	// Non-zero if the operation is a half of a move, where a "Delete" and an "Insert" operation share the same MoveID
+	MoveID int `json:"moveId,omitempty"`
+
+	// Hints for the animation, which are optional and set by diff.AddMonacoHints
+	//
+	// Same as Monaco's forceMoveMarkers, so that the cursor at the insert position moves to the end of the inserted text
+	ForceMoveMarkers bool `json:"forceMoveMarkers,omitempty"`
+	// Range to select before the operation, e.g. the text about to be deleted
+	Selection *Range `json:"selection,omitempty"`
+	// Cursor position after the operation
+	Cursor *Position `json:"cursor,omitempty"`
+	// Line to reveal before the operation, so that the operation is visible
+	RevealLine int `json:"revealLine,omitempty"`
+}
+
+// https://microsoft.github.io/monaco-editor/docs.html#interfaces/editor.IModelDecorationOptions.html
+type ModelDecorationOptions struct {
+	Description string `json:"description"`
+	// CSS class of the decorated text, or the whole line if IsWholeLine
+	ClassName   string `json:"className,omitempty"`
+	IsWholeLine bool   `json:"isWholeLine,omitempty"`
+	// CSS class of the gutter, between the line numbers and the text
+	LinesDecorationsClassName string `json:"linesDecorationsClassName,omitempty"`
+}
+
+// https://microsoft.github.io/monaco-editor/docs.html#interfaces/editor.IModelDeltaDecoration.html
+type ModelDeltaDecoration struct {
+	Range   Range                  `json:"range"`
+	Options ModelDecorationOptions `json:"options"`
 }
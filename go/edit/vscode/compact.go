@@ -0,0 +1,43 @@
+package vscode
+
+import "fmt"
+
+// Compact the sequence of edits into a shorter one with the same effect, by
+//
+//   - merging adjacent inserts, as in continuous typing
+//   - merging adjacent deletes, as in repeated forward-delete or backspace
+//   - cancelling a delete of text inserted just before, and an insert of text deleted just before
+//   - converting a delete followed by an insert at the same position into EditReplace
+//
+// Edits other than EditInsert, EditDelete and EditReplace are kept as they are, and never merged across.
+func Compact(edits []Edit) ([]Edit, error) {
+	errorPrefix := "vscode.Compact failed"
+
+	compacted := []Edit{}
+	for i, e := range edits {
+		current := e
+		for len(compacted) > 0 {
+			last := compacted[len(compacted)-1]
+			merged, ok, err := mergeEdits(last, current)
+			if err != nil {
+				return nil, fmt.Errorf("%s, edit[%d] = %+v, %s", errorPrefix, i, e, err)
+			}
+			if !ok {
+				break
+			}
+
+			// the merged edit may be merged with the one before, in the next iteration
+			compacted = compacted[:len(compacted)-1]
+			current = merged
+			if current == nil {
+				break // cancelled out
+			}
+		}
+
+		if current != nil {
+			compacted = append(compacted, current)
+		}
+	}
+
+	return compacted, nil
+}
@@ -0,0 +1,155 @@
+package vscode
+
+// Range of text inserted at start
+func insertedRange(start Position, text string) (Range, error) {
+	end, err := editRangeEnd(start, text)
+	if err != nil {
+		return Range{}, err
+	}
+	return Range{Start: start, End: end}, nil
+}
+
+func contains(outer, inner Range) bool {
+	return outer.Start.LessThanOrEqualTo(inner.Start) && inner.End.LessThanOrEqualTo(outer.End)
+}
+
+// Splice text, which starts at the position start, replacing the part in r by replacement
+func spliceText(text string, start Position, r Range, replacement string) (string, error) {
+	i, err := indexAt(text, start, r.Start)
+	if err != nil {
+		return "", err
+	}
+	j, err := indexAt(text, start, r.End)
+	if err != nil {
+		return "", err
+	}
+	return text[:i] + replacement + text[j:], nil
+}
+
+// Merge prev and the following edit next into a single edit if possible.
+// Return ok = false if they cannot be merged, and merged = nil with ok = true if they cancel out.
+func mergeEdits(prev, next Edit) (merged Edit, ok bool, err error) {
+	switch p := prev.(type) {
+	case EditInsert:
+		return mergeAfterInsert(p, next)
+	case EditDelete:
+		return mergeAfterDelete(p, next)
+	case EditReplace:
+		return mergeAfterReplace(p, next)
+	default:
+		return nil, false, nil
+	}
+}
+
+func mergeAfterInsert(p EditInsert, next Edit) (Edit, bool, error) {
+	inserted, err := insertedRange(p.Position, p.NewText)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch n := next.(type) {
+	case EditInsert:
+		// typing within, or right before or after the inserted text
+		if !contains(inserted, Range{Start: n.Position, End: n.Position}) {
+			return nil, false, nil
+		}
+		text, err := spliceText(p.NewText, p.Position, Range{Start: n.Position, End: n.Position}, n.NewText)
+		if err != nil {
+			return nil, false, err
+		}
+		return EditInsert{NewText: text, Position: p.Position}, true, nil
+
+	case EditDelete:
+		// deleting a part of the inserted text
+		if !contains(inserted, n.DeleteRange) {
+			return nil, false, nil
+		}
+		text, err := spliceText(p.NewText, p.Position, n.DeleteRange, "")
+		if err != nil {
+			return nil, false, err
+		}
+		if text == "" {
+			return nil, true, nil
+		}
+		return EditInsert{NewText: text, Position: p.Position}, true, nil
+
+	default:
+		return nil, false, nil
+	}
+}
+
+func mergeAfterDelete(p EditDelete, next Edit) (Edit, bool, error) {
+	switch n := next.(type) {
+	case EditDelete:
+		var text string
+		var start Position
+		if n.DeleteRange.Start == p.DeleteRange.Start {
+			// forward delete
+			text, start = p.DeleteText+n.DeleteText, p.DeleteRange.Start
+		} else if n.DeleteRange.End == p.DeleteRange.Start {
+			// backspace
+			text, start = n.DeleteText+p.DeleteText, n.DeleteRange.Start
+		} else {
+			return nil, false, nil
+		}
+
+		deleted, err := insertedRange(start, text)
+		if err != nil {
+			return nil, false, err
+		}
+		return EditDelete{DeleteText: text, DeleteRange: deleted}, true, nil
+
+	case EditInsert:
+		if n.Position != p.DeleteRange.Start {
+			return nil, false, nil
+		}
+		if n.NewText == p.DeleteText {
+			return nil, true, nil
+		}
+		return EditReplace{OldText: p.DeleteText, NewText: n.NewText, ReplaceRange: p.DeleteRange}, true, nil
+
+	default:
+		return nil, false, nil
+	}
+}
+
+func mergeAfterReplace(p EditReplace, next Edit) (Edit, bool, error) {
+	inserted, err := insertedRange(p.ReplaceRange.Start, p.NewText)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var text string
+	switch n := next.(type) {
+	case EditInsert:
+		// typing within, or right before or after the new text
+		at := Range{Start: n.Position, End: n.Position}
+		if !contains(inserted, at) {
+			return nil, false, nil
+		}
+		if text, err = spliceText(p.NewText, p.ReplaceRange.Start, at, n.NewText); err != nil {
+			return nil, false, err
+		}
+
+	case EditDelete:
+		// deleting a part of the new text
+		if !contains(inserted, n.DeleteRange) {
+			return nil, false, nil
+		}
+		if text, err = spliceText(p.NewText, p.ReplaceRange.Start, n.DeleteRange, ""); err != nil {
+			return nil, false, err
+		}
+
+	default:
+		return nil, false, nil
+	}
+
+	switch text {
+	case p.OldText:
+		return nil, true, nil
+	case "":
+		return EditDelete{DeleteText: p.OldText, DeleteRange: p.ReplaceRange}, true, nil
+	default:
+		return EditReplace{OldText: p.OldText, NewText: text, ReplaceRange: p.ReplaceRange}, true, nil
+	}
+}
@@ -0,0 +1,167 @@
+package vscode_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func pos(line, character int) vscode.Position {
+	return vscode.Position{Line: line, Character: character}
+}
+
+func TestCompact(t *testing.T) {
+	cases := map[string]struct {
+		edits    []vscode.Edit
+		expected []vscode.Edit
+	}{
+		"typing": {
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "a", Position: pos(0, 3)},
+				vscode.EditInsert{NewText: "b", Position: pos(0, 4)},
+				vscode.EditInsert{NewText: "\n", Position: pos(0, 5)},
+				vscode.EditInsert{NewText: "c", Position: pos(1, 0)},
+			},
+			[]vscode.Edit{vscode.EditInsert{NewText: "ab\nc", Position: pos(0, 3)}},
+		},
+		"new line first, then typing before it": {
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "\n", Position: pos(0, 3)},
+				vscode.EditInsert{NewText: "a", Position: pos(0, 3)},
+			},
+			[]vscode.Edit{vscode.EditInsert{NewText: "a\n", Position: pos(0, 3)}},
+		},
+		"forward delete": {
+			[]vscode.Edit{
+				vscode.EditDelete{DeleteText: "a", DeleteRange: vscode.Range{Start: pos(2, 1), End: pos(2, 2)}},
+				vscode.EditDelete{DeleteText: "b\n", DeleteRange: vscode.Range{Start: pos(2, 1), End: pos(3, 0)}},
+			},
+			[]vscode.Edit{vscode.EditDelete{DeleteText: "ab\n", DeleteRange: vscode.Range{Start: pos(2, 1), End: pos(3, 0)}}},
+		},
+		"backspace": {
+			[]vscode.Edit{
+				vscode.EditDelete{DeleteText: "c", DeleteRange: vscode.Range{Start: pos(0, 2), End: pos(0, 3)}},
+				vscode.EditDelete{DeleteText: "b", DeleteRange: vscode.Range{Start: pos(0, 1), End: pos(0, 2)}},
+			},
+			[]vscode.Edit{vscode.EditDelete{DeleteText: "bc", DeleteRange: vscode.Range{Start: pos(0, 1), End: pos(0, 3)}}},
+		},
+		"insert then delete cancel out": {
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "ab", Position: pos(0, 0)},
+				vscode.EditDelete{DeleteText: "b", DeleteRange: vscode.Range{Start: pos(0, 1), End: pos(0, 2)}},
+				vscode.EditDelete{DeleteText: "a", DeleteRange: vscode.Range{Start: pos(0, 0), End: pos(0, 1)}},
+			},
+			[]vscode.Edit{},
+		},
+		"delete then insert to replace": {
+			[]vscode.Edit{
+				vscode.EditDelete{DeleteText: "foo", DeleteRange: vscode.Range{Start: pos(1, 4), End: pos(1, 7)}},
+				vscode.EditInsert{NewText: "b", Position: pos(1, 4)},
+				vscode.EditInsert{NewText: "ar", Position: pos(1, 5)},
+			},
+			[]vscode.Edit{vscode.EditReplace{OldText: "foo", NewText: "bar", ReplaceRange: vscode.Range{Start: pos(1, 4), End: pos(1, 7)}}},
+		},
+		"delete then insert the same text": {
+			[]vscode.Edit{
+				vscode.EditDelete{DeleteText: "foo", DeleteRange: vscode.Range{Start: pos(1, 4), End: pos(1, 7)}},
+				vscode.EditInsert{NewText: "foo", Position: pos(1, 4)},
+			},
+			[]vscode.Edit{},
+		},
+		"not adjacent": {
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "a", Position: pos(0, 0)},
+				vscode.EditInsert{NewText: "b", Position: pos(0, 2)},
+				vscode.EditDelete{DeleteText: "c", DeleteRange: vscode.Range{Start: pos(1, 0), End: pos(1, 1)}},
+			},
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "a", Position: pos(0, 0)},
+				vscode.EditInsert{NewText: "b", Position: pos(0, 2)},
+				vscode.EditDelete{DeleteText: "c", DeleteRange: vscode.Range{Start: pos(1, 0), End: pos(1, 1)}},
+			},
+		},
+		"other edits are kept": {
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "a", Position: pos(0, 0)},
+				vscode.EditReindent{StartLine: 0, OldIndents: []string{""}, NewIndents: []string{"\t"}},
+				vscode.EditInsert{NewText: "b", Position: pos(0, 2)},
+			},
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "a", Position: pos(0, 0)},
+				vscode.EditReindent{StartLine: 0, OldIndents: []string{""}, NewIndents: []string{"\t"}},
+				vscode.EditInsert{NewText: "b", Position: pos(0, 2)},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := vscode.Compact(c.edits)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if d := cmp.Diff(c.expected, result); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+}
+
+// Type the insert rune by rune, and delete rune by rune with forward delete
+func runeByRune(e vscode.Edit) []vscode.Edit {
+	var edits []vscode.Edit
+	switch e := e.(type) {
+	case vscode.EditInsert:
+		p := e.Position
+		for _, r := range e.NewText {
+			edits = append(edits, vscode.EditInsert{NewText: string(r), Position: p})
+			if r == '\n' {
+				p = pos(p.Line+1, 0)
+			} else {
+				p = pos(p.Line, p.Character+1)
+			}
+		}
+	case vscode.EditDelete:
+		start := e.DeleteRange.Start
+		for _, r := range e.DeleteText {
+			end := pos(start.Line, start.Character+1)
+			if r == '\n' {
+				end = pos(start.Line+1, 0)
+			}
+			edits = append(edits, vscode.EditDelete{DeleteText: string(r), DeleteRange: vscode.Range{Start: start, End: end}})
+		}
+	}
+	return edits
+}
+
+// Compacting random edits split rune by rune keeps the result, and restores at most the original number of edits
+func TestCompactRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+
+	for i := 0; i < 1000; i++ {
+		doc := randomText(r, r.Intn(12))
+
+		original := randomEdits(t, r, doc, 1+r.Intn(5))
+		var split []vscode.Edit
+		for _, e := range original {
+			split = append(split, runeByRune(e)...)
+		}
+
+		compacted, err := vscode.Compact(split)
+		if err != nil {
+			t.Fatalf("doc = %q, edits = %+v, %s", doc, split, err)
+		}
+
+		// each original edit is compacted back into a single edit at least
+		if len(compacted) > len(original) {
+			t.Errorf("compacted %d edits into %d edits, more than the original %d edits", len(split), len(compacted), len(original))
+		}
+		expected := applyAll(t, doc, split...)
+		result := applyAll(t, doc, compacted...)
+		if expected != result {
+			t.Fatalf("doc = %q, edits = %+v, compacted = %+v, %s", doc, split, compacted, cmp.Diff(expected, result))
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package vscode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// In-memory text document, which applies edits incrementally by a piece table with a line index,
+// instead of re-scanning the whole text on every edit like Insert and Delete do.
+//
+// Offsets are byte offsets in the text, and positions are zero-based lines and characters (= runes), same as Position.
+type Document struct {
+	// Verify the text to delete, i.e. EditDelete's DeleteText, EditReplace's OldText and EditMove's MoveText,
+	// matches the text actually in the range, before Apply deletes it
+	VerifyDeleteText bool
+
+	original buffer
+	added    buffer
+	pieces   []piece
+	length   int // total bytes
+	newlines int // total '\n's
+}
+
+func NewDocument(text string) *Document {
+	d := &Document{}
+	d.reset(text)
+	return d
+}
+
+func (d *Document) Text() string {
+	var builder strings.Builder
+	builder.Grow(d.length)
+	for _, p := range d.pieces {
+		builder.Write(d.bytes(p))
+	}
+	return builder.String()
+}
+
+// Length of the text in bytes
+func (d *Document) Len() int {
+	return d.length
+}
+
+// Number of lines, where the text "a\nb\n" has 3 lines, the last of which is empty
+func (d *Document) LineCount() int {
+	return d.newlines + 1
+}
+
+// Text of the line, without the trailing '\n'
+func (d *Document) Line(line int) (string, error) {
+	start, err := d.lineStart(line)
+	if err != nil {
+		return "", fmt.Errorf("Document.Line() error, %s", err)
+	}
+
+	end := d.length
+	if line < d.newlines {
+		nextStart, _ := d.lineStart(line + 1)
+		end = nextStart - 1
+	}
+
+	return d.slice(start, end), nil
+}
+
+// Byte offset of the position
+func (d *Document) Offset(pos Position) (int, error) {
+	offset, err := d.offset(pos)
+	if err != nil {
+		return 0, fmt.Errorf("Document.Offset() error, %w", err)
+	}
+	return offset, nil
+}
+
+// Position of the byte offset
+func (d *Document) PositionAt(offset int) (Position, error) {
+	if offset < 0 || d.length < offset {
+		return Position{}, fmt.Errorf("Document.PositionAt() error, offset = %d is out of range [0, %d]", offset, d.length)
+	}
+
+	line := d.newlinesBefore(offset)
+	start, _ := d.lineStart(line)
+	return Position{Line: line, Character: countRunes(d.slice(start, offset))}, nil
+}
+
+func (d *Document) Insert(pos Position, text string) error {
+	errorPrefix := "Document.Insert() error"
+
+	offset, err := d.offset(pos)
+	if err != nil {
+		return fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+	d.insertAt(offset, text)
+
+	return nil
+}
+
+func (d *Document) Delete(delRange Range) error {
+	errorPrefix := "Document.Delete() error"
+
+	if err := delRange.Validate(); err != nil {
+		return fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+	start, err := d.offset(delRange.Start)
+	if err != nil {
+		return fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+	end, err := d.offset(delRange.End)
+	if err != nil {
+		return fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+	d.deleteRange(start, end)
+
+	return nil
+}
+
+// Apply the edit to the document.
+// Unknown edit types are applied through their Apply method, on the whole text.
+func (d *Document) Apply(edit Edit) error {
+	if d.VerifyDeleteText {
+		if err := d.verifyEdit(edit); err != nil {
+			return fmt.Errorf("Document.Apply() error, %w", err)
+		}
+	}
+
+	switch e := edit.(type) {
+	case EditInsert:
+		return d.Insert(e.Position, e.NewText)
+
+	case EditDelete:
+		return d.Delete(e.DeleteRange)
+
+	case EditReplace:
+		if err := d.Delete(e.ReplaceRange); err != nil {
+			return err
+		}
+		return d.Insert(e.ReplaceRange.Start, e.NewText)
+
+	case EditMove:
+		if err := d.Delete(e.FromRange); err != nil {
+			return err
+		}
+		return d.Insert(e.ToPosition, e.MoveText)
+
+	case EditReindent:
+		lineEdits, err := e.lineEdits()
+		if err != nil {
+			return err
+		}
+		// Always verify the old indents, regardless of VerifyDeleteText,
+		// since an out-of-date reindent would delete the code after the indent
+		for _, lineEdit := range lineEdits {
+			if del, ok := lineEdit.(EditDelete); ok {
+				if err := d.verify(del.DeleteRange, del.DeleteText); err != nil {
+					return fmt.Errorf("Document.Apply() error, %w", err)
+				}
+			}
+		}
+		for _, lineEdit := range lineEdits {
+			if err := d.Apply(lineEdit); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		after, err := edit.Apply(d.Text())
+		if err != nil {
+			return err
+		}
+		d.reset(after)
+		return nil
+	}
+}
+
+// Options used by ApplyEditsWithOptions
+type ApplyOptions struct {
+	// Same as Document.VerifyDeleteText, to return *ErrDeleteTextMismatch instead of deleting unexpected text
+	VerifyDeleteText bool
+}
+
+// Apply the edits in order to before, and return the result
+func ApplyEdits(before string, edits []Edit) (string, error) {
+	return ApplyEditsWithOptions(before, edits, ApplyOptions{})
+}
+
+// Same as ApplyEdits, but with options, e.g. to verify EditDelete's DeleteText, which Edit.Apply never does
+func ApplyEditsWithOptions(before string, edits []Edit, opts ApplyOptions) (string, error) {
+	doc := NewDocument(before)
+	doc.VerifyDeleteText = opts.VerifyDeleteText
+	for i, e := range edits {
+		if err := doc.Apply(e); err != nil {
+			return "", fmt.Errorf("ApplyEdits() error at edit[%d], %w", i, err)
+		}
+	}
+	return doc.Text(), nil
+}
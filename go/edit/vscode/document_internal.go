@@ -0,0 +1,266 @@
+package vscode
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Append-only text buffer, with the offsets of '\n's as the line index
+type buffer struct {
+	text       []byte
+	lineBreaks []int
+}
+
+func (b *buffer) append(text string) {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			b.lineBreaks = append(b.lineBreaks, len(b.text)+i)
+		}
+	}
+	b.text = append(b.text, text...)
+}
+
+// Number of '\n's in text[start:end]
+func (b *buffer) newlines(start, end int) int {
+	return sort.SearchInts(b.lineBreaks, end) - sort.SearchInts(b.lineBreaks, start)
+}
+
+// Span of either the original or the added buffer
+type piece struct {
+	added    bool
+	start    int
+	end      int
+	newlines int
+}
+
+func (p piece) len() int {
+	return p.end - p.start
+}
+
+func countRunes(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// Apply the single edit to before by Document, where edit must be one of the types Document.Apply handles directly
+func applyToDocument(before string, edit Edit) (string, error) {
+	doc := NewDocument(before)
+	if err := doc.Apply(edit); err != nil {
+		return "", err
+	}
+	return doc.Text(), nil
+}
+
+// Verify the text to delete matches the text actually in the range
+func (d *Document) verify(r Range, expected string) error {
+	start, err := d.offset(r.Start)
+	if err != nil {
+		return err
+	}
+	end, err := d.offset(r.End)
+	if err != nil {
+		return err
+	}
+	if actual := d.slice(start, end); actual != expected {
+		return &ErrDeleteTextMismatch{Range: r, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+func (d *Document) verifyEdit(edit Edit) error {
+	switch e := edit.(type) {
+	case EditDelete:
+		return d.verify(e.DeleteRange, e.DeleteText)
+	case EditReplace:
+		return d.verify(e.ReplaceRange, e.OldText)
+	case EditMove:
+		return d.verify(e.FromRange, e.MoveText)
+	default:
+		return nil
+	}
+}
+
+func (d *Document) reset(text string) {
+	d.original = buffer{}
+	d.original.append(text)
+	d.added = buffer{}
+	d.pieces = nil
+	if len(text) > 0 {
+		d.pieces = []piece{{added: false, start: 0, end: len(text), newlines: len(d.original.lineBreaks)}}
+	}
+	d.length = len(text)
+	d.newlines = len(d.original.lineBreaks)
+}
+
+func (d *Document) buffer(p piece) *buffer {
+	if p.added {
+		return &d.added
+	}
+	return &d.original
+}
+
+func (d *Document) bytes(p piece) []byte {
+	return d.buffer(p).text[p.start:p.end]
+}
+
+func (d *Document) newPiece(added bool, start, end int) piece {
+	p := piece{added: added, start: start, end: end}
+	p.newlines = d.buffer(p).newlines(start, end)
+	return p
+}
+
+// Text in the byte offsets [start, end)
+func (d *Document) slice(start, end int) string {
+	var result []byte
+	pieceStart := 0
+	for _, p := range d.pieces {
+		pieceEnd := pieceStart + p.len()
+		if pieceEnd > start && pieceStart < end {
+			from, to := max(start, pieceStart)-pieceStart, min(end, pieceEnd)-pieceStart
+			result = append(result, d.bytes(p)[from:to]...)
+		}
+		if pieceEnd >= end {
+			break
+		}
+		pieceStart = pieceEnd
+	}
+	return string(result)
+}
+
+// Byte offset of the start of the line
+func (d *Document) lineStart(line int) (int, error) {
+	if line < 0 || d.newlines < line {
+		return 0, fmt.Errorf("line = %d is out of range, as there are only %d lines", line, d.LineCount())
+	}
+	if line == 0 {
+		return 0, nil
+	}
+
+	// Find the piece containing the (line)-th '\n'
+	remaining := line
+	pieceStart := 0
+	for _, p := range d.pieces {
+		if remaining <= p.newlines {
+			b := d.buffer(p)
+			k := sort.SearchInts(b.lineBreaks, p.start) + remaining - 1
+			return pieceStart + b.lineBreaks[k] - p.start + 1, nil
+		}
+		remaining -= p.newlines
+		pieceStart += p.len()
+	}
+
+	panic("unreachable, as the number of '\\n's in pieces must be equal to d.newlines")
+}
+
+// Number of '\n's before the byte offset
+func (d *Document) newlinesBefore(offset int) int {
+	count := 0
+	pieceStart := 0
+	for _, p := range d.pieces {
+		if pieceStart+p.len() <= offset {
+			count += p.newlines
+		} else {
+			count += d.buffer(p).newlines(p.start, p.start+offset-pieceStart)
+			break
+		}
+		pieceStart += p.len()
+	}
+	return count
+}
+
+// Byte offset of the position
+func (d *Document) offset(pos Position) (int, error) {
+	if err := pos.Validate(); err != nil {
+		return 0, err
+	}
+
+	if d.newlines < pos.Line {
+		return 0, &ErrLineOutOfRange{Position: pos, LineCount: d.LineCount()}
+	}
+	start, _ := d.lineStart(pos.Line)
+
+	// Walk runes from the line start, where a rune never spans pieces
+	offset, chars := start, 0
+	pieceStart := 0
+	for _, p := range d.pieces {
+		if chars == pos.Character {
+			break
+		}
+		pieceEnd := pieceStart + p.len()
+		bytes := d.bytes(p)
+		for chars < pos.Character && pieceStart <= offset && offset < pieceEnd {
+			r, size := utf8.DecodeRune(bytes[offset-pieceStart:])
+			if r == '\n' {
+				break
+			}
+			offset += size
+			chars++
+		}
+		pieceStart = pieceEnd
+	}
+
+	if chars < pos.Character {
+		return 0, &ErrCharacterOutOfRange{Position: pos, LineLength: chars}
+	}
+	return offset, nil
+}
+
+// Split the piece at the byte offset if needed, and return the index of the piece starting at the offset
+func (d *Document) split(offset int) int {
+	pieceStart := 0
+	for i, p := range d.pieces {
+		if offset == pieceStart {
+			return i
+		}
+		pieceEnd := pieceStart + p.len()
+		if offset < pieceEnd {
+			at := p.start + offset - pieceStart
+			left := d.newPiece(p.added, p.start, at)
+			right := d.newPiece(p.added, at, p.end)
+			d.pieces = append(d.pieces[:i], append([]piece{left, right}, d.pieces[i+1:]...)...)
+			return i + 1
+		}
+		pieceStart = pieceEnd
+	}
+	return len(d.pieces)
+}
+
+func (d *Document) insertAt(offset int, text string) {
+	if text == "" {
+		return
+	}
+
+	start := len(d.added.text)
+	d.added.append(text)
+	inserted := d.newPiece(true, start, len(d.added.text))
+
+	d.length += inserted.len()
+	d.newlines += inserted.newlines
+
+	i := d.split(offset)
+
+	// Continuous typing extends the last added piece, instead of adding a new piece for every edit
+	if i > 0 {
+		prev := &d.pieces[i-1]
+		if prev.added && prev.end == start {
+			prev.end = inserted.end
+			prev.newlines += inserted.newlines
+			return
+		}
+	}
+
+	d.pieces = append(d.pieces[:i], append([]piece{inserted}, d.pieces[i:]...)...)
+}
+
+func (d *Document) deleteRange(start, end int) {
+	if start == end {
+		return
+	}
+
+	d.length -= end - start
+	d.newlines -= d.newlinesBefore(end) - d.newlinesBefore(start)
+
+	i := d.split(start)
+	j := d.split(end)
+	d.pieces = append(d.pieces[:i], d.pieces[j:]...)
+}
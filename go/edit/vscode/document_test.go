@@ -0,0 +1,142 @@
+package vscode_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestDocumentLookups(t *testing.T) {
+	doc := vscode.NewDocument("package main\n\nfunc 日本() {}\n")
+
+	if doc.LineCount() != 4 {
+		t.Errorf("expected 4 lines, but got %d", doc.LineCount())
+	}
+
+	lines := []string{"package main", "", "func 日本() {}", ""}
+	for i, expected := range lines {
+		line, err := doc.Line(i)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if expected != line {
+			t.Errorf("line %d: %s", i, cmp.Diff(expected, line))
+		}
+	}
+
+	cases := map[string]struct {
+		pos    vscode.Position
+		offset int
+		err    bool
+	}{
+		"beginning":                {vscode.Position{Line: 0, Character: 0}, 0, false},
+		"end of line":              {vscode.Position{Line: 0, Character: 12}, 12, false},
+		"empty line":               {vscode.Position{Line: 1, Character: 0}, 13, false},
+		"after multi-byte chars":   {vscode.Position{Line: 2, Character: 7}, 14 + 5 + 6, false},
+		"last empty line":          {vscode.Position{Line: 3, Character: 0}, 31, false},
+		"ERROR: after end of line": {vscode.Position{Line: 0, Character: 13}, 0, true},
+		"ERROR: after last line":   {vscode.Position{Line: 4, Character: 0}, 0, true},
+		"ERROR: negative":          {vscode.Position{Line: -1, Character: 0}, 0, true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			offset, err := doc.Offset(c.pos)
+			if err != nil {
+				if c.err {
+					return // expected error
+				}
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if c.err {
+				t.Fatalf("Expected error: but succeeded with result = %d", offset)
+			}
+			if c.offset != offset {
+				t.Errorf("expected offset = %d, but got %d", c.offset, offset)
+			}
+
+			pos, err := doc.PositionAt(offset)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if d := cmp.Diff(c.pos, pos); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+}
+
+// Applying random edits to Document gives the same result as applying them one by one to the string
+func TestDocumentRandomEdits(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+
+	for i := 0; i < 500; i++ {
+		before := randomText(r, r.Intn(20))
+		edits := randomEdits(t, r, before, 1+r.Intn(10))
+
+		doc := vscode.NewDocument(before)
+		expected := before
+		for k, e := range edits {
+			if err := doc.Apply(e); err != nil {
+				t.Fatalf("before = %q, failed to apply edit[%d] = %+v, %s", before, k, e, err)
+			}
+			expected = applyAll(t, expected, e)
+
+			if text := doc.Text(); expected != text {
+				t.Fatalf("before = %q, edits = %+v, at edit[%d], %s", before, edits, k, cmp.Diff(expected, text))
+			}
+			if lines := strings.Count(expected, "\n") + 1; lines != doc.LineCount() {
+				t.Fatalf("expected %d lines, but got %d", lines, doc.LineCount())
+			}
+		}
+	}
+}
+
+// Large text, and char-level edits typing a line in the middle
+func benchmarkInput(lines int) (string, []vscode.Edit) {
+	var builder strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&builder, "\tresult := a + b + %d\n", i)
+	}
+
+	var edits []vscode.Edit
+	for i, r := range "fmt.Println(result)\n" {
+		edits = append(edits, vscode.EditInsert{NewText: string(r), Position: vscode.Position{Line: lines / 2, Character: i}})
+	}
+	return builder.String(), edits
+}
+
+func BenchmarkApplyString(b *testing.B) {
+	for _, lines := range []int{1000, 10000} {
+		before, edits := benchmarkInput(lines)
+		b.Run(fmt.Sprintf("%d lines", lines), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				text := before
+				for _, e := range edits {
+					var err error
+					if text, err = vscode.Insert(strings.NewReader(text), e.(vscode.EditInsert).Position, e.(vscode.EditInsert).NewText); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkApplyDocument(b *testing.B) {
+	for _, lines := range []int{1000, 10000} {
+		before, edits := benchmarkInput(lines)
+		b.Run(fmt.Sprintf("%d lines", lines), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				if _, err := vscode.ApplyEdits(before, edits); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
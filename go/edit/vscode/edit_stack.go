@@ -30,6 +30,13 @@ func (s *EditStack) AppendInsert(text string) {
 	s.diffs = append(s.diffs, insert)
 }
 
+// Return a copy of the diffs appended so far
+func (s *EditStack) Diffs() []Diff {
+	diffs := make([]Diff, len(s.diffs))
+	copy(diffs, s.diffs)
+	return diffs
+}
+
 func (s *EditStack) CalcEdits( /*TODO: splitStrategy: Strategy */ ) ([]Edit, error) {
 	pos := Position{0, 0}
 	edits := []Edit{}
@@ -62,12 +69,7 @@ func (s *EditStack) CalcMonacoEdits() ([]monaco.SingleEditOperation, error) {
 
 		switch diff.Type {
 		case DiffInsert:
-			mRange := monaco.Range{
-				StartColumn:     currentPos.Character + 1,
-				StartLineNumber: currentPos.Line + 1,
-				EndColumn:       currentPos.Character + 1,
-				EndLineNumber:   currentPos.Line + 1,
-			}
+			mRange := toMonacoRange(currentPos, currentPos)
 			edits = append(edits, monaco.SingleEditOperation{Text: diff.Text, Range: mRange, Operation: "Insert"})
 			currentPos = rangeEndPos
 
@@ -75,12 +77,7 @@ func (s *EditStack) CalcMonacoEdits() ([]monaco.SingleEditOperation, error) {
 			currentPos = rangeEndPos
 
 		case DiffDelete:
-			mRange := monaco.Range{
-				StartColumn:     currentPos.Character + 1,
-				StartLineNumber: currentPos.Line + 1,
-				EndColumn:       rangeEndPos.Character + 1,
-				EndLineNumber:   rangeEndPos.Line + 1,
-			}
+			mRange := toMonacoRange(currentPos, rangeEndPos)
 			edits = append(edits, monaco.SingleEditOperation{Text: "" /*empty text for delete*/, Range: mRange, Operation: "Delete"})
 			// currentPos doesn't move after delete
 
@@ -91,3 +88,38 @@ func (s *EditStack) CalcMonacoEdits() ([]monaco.SingleEditOperation, error) {
 
 	return edits, nil
 }
+
+// Same as CalcMonacoEdits, but all the ranges are relative to the text before the edits, same as Monaco's executeEdits
+// applying a single batch of operations, instead of each range assuming the preceding operations are applied.
+// A delete adjacent to an insert becomes a single "Replace" operation, so that no two operations start at the same position.
+func (s *EditStack) CalcMonacoBatchEdits() ([]monaco.SingleEditOperation, error) {
+	currentPos := Position{0, 0}
+	edits := []monaco.SingleEditOperation{}
+
+	for _, diff := range s.diffs {
+		rangeEndPos, err := editRangeEnd(currentPos, diff.Text)
+		if err != nil {
+			return nil, err
+		}
+
+		switch diff.Type {
+		case DiffInsert:
+			mRange := toMonacoRange(currentPos, currentPos)
+			edits = appendBatchEdit(edits, monaco.SingleEditOperation{Text: diff.Text, Range: mRange, Operation: "Insert"})
+			// currentPos doesn't move after insert, as the position is in the text before the edits
+
+		case DiffEqual:
+			currentPos = rangeEndPos
+
+		case DiffDelete:
+			mRange := toMonacoRange(currentPos, rangeEndPos)
+			edits = appendBatchEdit(edits, monaco.SingleEditOperation{Text: "" /*empty text for delete*/, Range: mRange, Operation: "Delete"})
+			currentPos = rangeEndPos
+
+		default:
+			return nil, fmt.Errorf("diff type = %d is invalid", diff.Type)
+		}
+	}
+
+	return edits, nil
+}
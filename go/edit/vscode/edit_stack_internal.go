@@ -3,8 +3,27 @@ package vscode
 import (
 	"fmt"
 	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
 )
 
+// Monaco's line numbers and columns are one-based, while vscode's are zero-based
+func toMonacoRange(start, end Position) monaco.Range {
+	return monaco.Range{
+		StartColumn:     start.Character + 1,
+		StartLineNumber: start.Line + 1,
+		EndColumn:       end.Character + 1,
+		EndLineNumber:   end.Line + 1,
+	}
+}
+
+func fromMonacoRange(r monaco.Range) Range {
+	return Range{
+		Start: Position{Line: r.StartLineNumber - 1, Character: r.StartColumn - 1},
+		End:   Position{Line: r.EndLineNumber - 1, Character: r.EndColumn - 1},
+	}
+}
+
 // Calculate the edit text's range end position.
 //
 // Regardless of the edit type, either insert, equal nor deletion, the end position is same,
@@ -73,3 +92,51 @@ func diffToEdit(currentPos Position, diff Diff) (Edit, Position, error) {
 		return nil, Position{}, fmt.Errorf("diff type = %d is invalid", diff.Type)
 	}
 }
+
+// Convert a split edit back into a Monaco operation
+func monacoEdit(edit Edit) (monaco.SingleEditOperation, error) {
+	switch e := edit.(type) {
+	case EditInsert:
+		return e.MonacoEdit(), nil
+	case EditDelete:
+		return e.MonacoEdit(), nil
+	case EditReplace:
+		return e.MonacoEdit(), nil
+	default:
+		return monaco.SingleEditOperation{}, fmt.Errorf("cannot convert edit = %+v to a single Monaco operation", edit)
+	}
+}
+
+// Append the operation to a batch, merging it into the last operation if they are a delete and an insert touching each other
+func appendBatchEdit(edits []monaco.SingleEditOperation, op monaco.SingleEditOperation) []monaco.SingleEditOperation {
+	if len(edits) == 0 {
+		return append(edits, op)
+	}
+
+	last := &edits[len(edits)-1]
+	lastEnd := Position{Line: last.Range.EndLineNumber, Character: last.Range.EndColumn}
+	opStart := Position{Line: op.Range.StartLineNumber, Character: op.Range.StartColumn}
+	if lastEnd != opStart {
+		return append(edits, op)
+	}
+
+	switch {
+	case last.Operation == "Delete" && op.Operation == "Insert":
+		// insert at the end of the deleted range
+		last.Text = op.Text
+		last.Operation = "Replace"
+	case last.Operation == "Insert" && op.Operation == "Delete":
+		// delete right after the inserted position
+		last.Range = monaco.Range{
+			StartColumn:     last.Range.StartColumn,
+			StartLineNumber: last.Range.StartLineNumber,
+			EndColumn:       op.Range.EndColumn,
+			EndLineNumber:   op.Range.EndLineNumber,
+		}
+		last.Operation = "Replace"
+	default:
+		return append(edits, op)
+	}
+
+	return edits
+}
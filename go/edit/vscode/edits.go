@@ -1,6 +1,11 @@
 package vscode
 
-import "strings"
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+)
 
 type SplitStrategy int
 
@@ -8,6 +13,8 @@ const (
 	SplitByLine SplitStrategy = 1
 	SplitByWord SplitStrategy = 2
 	SplitByChar SplitStrategy = 3
+	// Split into identifiers, runs of whitespace, and single punctuation chars, which looks like typing code
+	SplitByToken SplitStrategy = 4
 )
 
 type EditOperation int
@@ -34,13 +41,15 @@ type Edit interface {
 
 // Concrete edit types
 type EditInsert struct {
-	NewText  string
-	Position Position
+	NewText  string   `json:"newText"`
+	Position Position `json:"position"`
 }
 
+// Apply and ApplyToFile don't verify DeleteText against the actual text in DeleteRange.
+// Use ApplyVerified and ApplyToFileVerified, or ApplyEditsWithOptions and ApplyEditsToFile with VerifyDeleteText, to verify it.
 type EditDelete struct {
-	DeleteText  string // DeleteText is necessary for word-by-word split, and char-by-char split
-	DeleteRange Range
+	DeleteText  string `json:"deleteText"` // DeleteText is necessary for word-by-word split, and char-by-char split
+	DeleteRange Range  `json:"deleteRange"`
 
 	// Why does it have *both* DeleteText and DeleteRange?
 	// reason: avoid erorr handling every time getting end pos.
@@ -56,9 +65,32 @@ type EditDelete struct {
 	// So, better to store the entire Range instead.
 }
 
+// Replace OldText in ReplaceRange by NewText, same as VS Code's TextEdit
+type EditReplace struct {
+	OldText      string `json:"oldText"`
+	NewText      string `json:"newText"`
+	ReplaceRange Range  `json:"replaceRange"`
+}
+
+// Cut the text in FromRange, and paste it at ToPosition.
+// ToPosition is the position *after* the cut, so EditMove is equivalent to EditDelete followed by EditInsert,
+// but animated as a selection-and-drag instead of delete and retype.
+type EditMove struct {
+	MoveText   string   `json:"moveText"`
+	FromRange  Range    `json:"fromRange"`
+	ToPosition Position `json:"toPosition"`
+}
+
+// Replace only the leading whitespace of consecutive lines from StartLine, OldIndents[i] by NewIndents[i] on line StartLine+i.
+// Animated as a block indent or outdent, instead of deleting and retyping the whole lines.
+type EditReindent struct {
+	StartLine  int      `json:"startLine"`
+	OldIndents []string `json:"oldIndents"`
+	NewIndents []string `json:"newIndents"`
+}
+
 func (e EditInsert) Apply(before string) (string, error) {
-	reader := strings.NewReader(before)
-	return Insert(reader, e.Position, e.NewText)
+	return applyToDocument(before, e)
 }
 
 func (e EditInsert) ApplyToFile(filename string) error {
@@ -66,14 +98,75 @@ func (e EditInsert) ApplyToFile(filename string) error {
 }
 
 func (e EditDelete) Apply(before string) (string, error) {
-	reader := strings.NewReader(before)
-	return Delete(reader, e.DeleteRange)
+	return applyToDocument(before, e)
 }
 
 func (e EditDelete) ApplyToFile(filename string) error {
 	return DeleteInFile(filename, e.DeleteRange)
 }
 
+// Same as Apply, but returns *ErrDeleteTextMismatch wrapped if DeleteText doesn't match the text in DeleteRange
+func (e EditDelete) ApplyVerified(before string) (string, error) {
+	return ApplyEditsWithOptions(before, []Edit{e}, ApplyOptions{VerifyDeleteText: true})
+}
+
+// Same as ApplyVerified, but for the file, which is written by ApplyEditsToFile
+func (e EditDelete) ApplyToFileVerified(filename string) error {
+	return ApplyEditsToFile(filename, []Edit{e}, FileOptions{VerifyDeleteText: true})
+}
+
+func (e EditReplace) Apply(before string) (string, error) {
+	return applyToDocument(before, e)
+}
+
+func (e EditReplace) ApplyToFile(filename string) error {
+	return ApplyEditsToFile(filename, []Edit{e}, FileOptions{})
+}
+
+func (e EditMove) Apply(before string) (string, error) {
+	return applyToDocument(before, e)
+}
+
+// Monaco has no move operation, so return a pair of "Delete" and "Insert" operations tagged with moveID.
+// The "Insert" operation's range assumes the "Delete" operation is already applied.
+func (e EditMove) MonacoEdits(moveID int) []monaco.SingleEditOperation {
+	return []monaco.SingleEditOperation{
+		{Text: "", Range: toMonacoRange(e.FromRange.Start, e.FromRange.End), Operation: "Delete", MoveID: moveID},
+		{Text: e.MoveText, Range: toMonacoRange(e.ToPosition, e.ToPosition), Operation: "Insert", MoveID: moveID},
+	}
+}
+
+func (e EditMove) ApplyToFile(filename string) error {
+	return ApplyEditsToFile(filename, []Edit{e}, FileOptions{})
+}
+
+func (e EditReindent) Apply(before string) (string, error) {
+	return applyToDocument(before, e)
+}
+
+func (e EditReindent) ApplyToFile(filename string) error {
+	return ApplyEditsToFile(filename, []Edit{e}, FileOptions{})
+}
+
+// Return "Reindent" operations, each of which replaces the old indent by the new indent on a line
+func (e EditReindent) MonacoEdits() ([]monaco.SingleEditOperation, error) {
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+
+	var edits []monaco.SingleEditOperation
+	for i := range e.OldIndents {
+		if e.OldIndents[i] == e.NewIndents[i] {
+			continue
+		}
+		line := e.StartLine + i
+		start := Position{Line: line, Character: 0}
+		end := Position{Line: line, Character: utf8.RuneCountInString(e.OldIndents[i])}
+		edits = append(edits, monaco.SingleEditOperation{Text: e.NewIndents[i], Range: toMonacoRange(start, end), Operation: "Reindent"})
+	}
+	return edits, nil
+}
+
 func (e EditInsert) Split(strategy SplitStrategy) ([]Edit, error) {
 	switch strategy {
 	case SplitByLine:
@@ -82,6 +175,8 @@ func (e EditInsert) Split(strategy SplitStrategy) ([]Edit, error) {
 		return splitInsertByWord(e)
 	case SplitByChar:
 		return splitInsertByChar(e)
+	case SplitByToken:
+		return splitInsertByToken(e)
 	default:
 		return nil, nil
 	}
@@ -95,7 +190,43 @@ func (e EditDelete) Split(strategy SplitStrategy) ([]Edit, error) {
 		return splitDeleteByWord(e)
 	case SplitByChar:
 		return splitDeleteByChar(e)
+	case SplitByToken:
+		return splitDeleteByToken(e)
 	default:
 		return nil, nil
 	}
 }
+
+// Split as select-then-type, i.e. the selected OldText is replaced by the first chunk of NewText in one step,
+// and then the rest of NewText is typed chunk by chunk, where chunks are split by the strategy
+func (e EditReplace) Split(strategy SplitStrategy) ([]Edit, error) {
+	if e.NewText == "" {
+		return []Edit{EditDelete{DeleteText: e.OldText, DeleteRange: e.ReplaceRange}}, nil
+	}
+
+	inserts, err := EditInsert{NewText: e.NewText, Position: e.ReplaceRange.Start}.Split(strategy)
+	if err != nil {
+		return nil, err
+	}
+	if e.OldText == "" || len(inserts) == 0 {
+		return inserts, nil
+	}
+
+	// The first insert is at the start of the range, so it can replace the selection
+	first, ok := inserts[0].(EditInsert)
+	if !ok || first.Position != e.ReplaceRange.Start {
+		return nil, fmt.Errorf("EditReplace.Split() error, unexpected first chunk = %+v", inserts[0])
+	}
+	replace := EditReplace{OldText: e.OldText, NewText: first.NewText, ReplaceRange: e.ReplaceRange}
+	return append([]Edit{replace}, inserts[1:]...), nil
+}
+
+// EditMove is not split by any strategy, since cut-and-paste is a single step in the animation
+func (e EditMove) Split(strategy SplitStrategy) ([]Edit, error) {
+	return []Edit{e}, nil
+}
+
+// EditReindent is not split by any strategy, since the block indent is a single step in the animation
+func (e EditReindent) Split(strategy SplitStrategy) ([]Edit, error) {
+	return []Edit{e}, nil
+}
@@ -2,8 +2,11 @@ package vscode
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/richardimaoka/typing-animation/go/internal/tokens"
 )
 
 // Return edits, split by char, to add a line from currentPos
@@ -108,6 +111,15 @@ func deleteLineByChar(startPos Position, line string) ([]Edit, error) {
 // If line contains '\n' in the middle, this returns an error
 // If line is empty, this should return the count of zero
 func insertLineByWord(currentPos Position, line string) ([]Edit, error) {
+	return insertLineByChunks(currentPos, line, splitWords)
+}
+
+// Same as insertLineByWord, but split by tokens
+func insertLineByToken(currentPos Position, line string) ([]Edit, error) {
+	return insertLineByChunks(currentPos, line, tokens.Tokenize)
+}
+
+func insertLineByChunks(currentPos Position, line string, split func(string) []string) ([]Edit, error) {
 	if len(line) == 0 {
 		return nil, nil
 	}
@@ -120,7 +132,7 @@ func insertLineByWord(currentPos Position, line string) ([]Edit, error) {
 		edits = append(edits, EditInsert{Position: currentPos, NewText: "\n"})
 	}
 
-	lineWords := strings.SplitAfter(lineWithoutNL, " ")
+	lineWords := split(lineWithoutNL)
 
 	pos := currentPos
 	for _, word := range lineWords {
@@ -151,6 +163,15 @@ func insertLineByWord(currentPos Position, line string) ([]Edit, error) {
 // If line contains '\n' in the middle, this returns an error
 // If line is empty, this should return the count of zero
 func deleteLineByWord(currentPos Position, line string) ([]Edit, error) {
+	return deleteLineByChunks(currentPos, line, splitWords)
+}
+
+// Same as deleteLineByWord, but split by tokens
+func deleteLineByToken(currentPos Position, line string) ([]Edit, error) {
+	return deleteLineByChunks(currentPos, line, tokens.Tokenize)
+}
+
+func deleteLineByChunks(currentPos Position, line string, split func(string) []string) ([]Edit, error) {
 	if len(line) == 0 {
 		return nil, nil
 	} else if line == "\n" {
@@ -164,7 +185,7 @@ func deleteLineByWord(currentPos Position, line string) ([]Edit, error) {
 
 	// Necessary to cut the last '\n', since countRunesInLine() expects no '\n' in the line
 	lineWithoutNL, hasNewLine := strings.CutSuffix(line, "\n")
-	lineWords := strings.SplitAfter(lineWithoutNL, " ")
+	lineWords := split(lineWithoutNL)
 
 	for _, word := range lineWords {
 		if word == "" {
@@ -224,37 +245,32 @@ func splitInsertByLine(insert EditInsert) ([]Edit, error) {
 }
 
 func splitDeleteByLine(delete EditDelete) ([]Edit, error) {
+	// All the lines are deleted at the start position, as the following lines move up after each delete,
+	// same as splitDeleteByWord
 	start := delete.DeleteRange.Start
 	end := delete.DeleteRange.End
 
 	lines := strings.SplitAfter(delete.DeleteText, "\n")
 
 	var edits []Edit
-	for lc := 0; lc < len(lines); lc++ {
-		line := lines[lc]
-
+	for _, line := range lines {
 		// if NewText ends in '\n', the last line is ""
 		if line == "" {
 			continue
 		}
 
-		var lineStart Position
-		if lc == 0 {
-			// line at the start position
-			lineStart = Position{Line: start.Line + lc, Character: start.Character}
-		} else {
-			lineStart = Position{Line: start.Line + lc, Character: 0}
-		}
-
 		var lineEnd Position
-		if lc == len(lines)-1 {
-			// line at the end position
-			lineEnd = Position{Line: start.Line + lc, Character: end.Character}
+		if strings.HasSuffix(line, "\n") {
+			lineEnd = Position{Line: start.Line + 1, Character: 0}
 		} else {
-			lineEnd = Position{Line: start.Line + lc + 1, Character: 0}
+			// the last line, which ends at the end position
+			lineEnd = Position{Line: start.Line, Character: start.Character + end.Character}
+			if end.Line == start.Line {
+				lineEnd.Character = end.Character
+			}
 		}
 
-		edits = append(edits, EditDelete{DeleteText: line, DeleteRange: Range{Start: lineStart, End: lineEnd}})
+		edits = append(edits, EditDelete{DeleteText: line, DeleteRange: Range{Start: start, End: lineEnd}})
 	}
 
 	return edits, nil
@@ -295,6 +311,41 @@ func splitDeleteByWord(delete EditDelete) ([]Edit, error) {
 	return edits, nil
 }
 
+func splitInsertByToken(insert EditInsert) ([]Edit, error) {
+	pos := insert.Position
+	lines := strings.SplitAfter(insert.NewText, "\n")
+
+	var edits []Edit
+	for _, l := range lines {
+		lineEdits, err := insertLineByToken(pos, l)
+		if err != nil {
+			return nil, err
+		}
+
+		edits = append(edits, lineEdits...)
+		pos = Position{Line: pos.Line + 1, Character: 0}
+	}
+
+	return edits, nil
+}
+
+func splitDeleteByToken(delete EditDelete) ([]Edit, error) {
+	startPos := delete.DeleteRange.Start
+	lines := strings.SplitAfter(delete.DeleteText, "\n")
+
+	var edits []Edit
+	for _, l := range lines {
+		lineEdits, err := deleteLineByToken(startPos, l)
+		if err != nil {
+			return nil, err
+		}
+
+		edits = append(edits, lineEdits...)
+	}
+
+	return edits, nil
+}
+
 func splitInsertByChar(insert EditInsert) ([]Edit, error) {
 	pos := insert.Position
 	lines := strings.SplitAfter(insert.NewText, "\n")
@@ -314,19 +365,65 @@ func splitInsertByChar(insert EditInsert) ([]Edit, error) {
 }
 
 func splitDeleteByChar(delete EditDelete) ([]Edit, error) {
-	pos := delete.DeleteRange.Start
-	lines := strings.Split(delete.DeleteText, "\n")
+	// All the chars are deleted at the start position, as the following text moves to the start after each delete,
+	// same as splitDeleteByWord
+	startPos := delete.DeleteRange.Start
+	lines := strings.SplitAfter(delete.DeleteText, "\n")
 
 	var edits []Edit
 	for _, l := range lines {
-		lineEdits, err := deleteLineByChar(pos, l)
+		lineWithoutNL, hasNewLine := strings.CutSuffix(l, "\n")
+		lineEdits, err := deleteLineByChar(startPos, lineWithoutNL)
 		if err != nil {
 			return nil, err
 		}
-
 		edits = append(edits, lineEdits...)
-		pos = Position{Line: pos.Line + 1, Character: 0}
+
+		if hasNewLine {
+			edits = append(edits, EditDelete{
+				DeleteText:  "\n",
+				DeleteRange: Range{Start: startPos, End: Position{Line: startPos.Line + 1, Character: 0}},
+			})
+		}
 	}
 
 	return edits, nil
 }
+
+func (e EditReindent) validate() error {
+	if len(e.OldIndents) != len(e.NewIndents) {
+		return fmt.Errorf("EditReindent has %d old indents, but %d new indents", len(e.OldIndents), len(e.NewIndents))
+	}
+	if e.StartLine < 0 {
+		return fmt.Errorf("EditReindent has negative start line = %d", e.StartLine)
+	}
+	for i := range e.OldIndents {
+		if strings.Trim(e.OldIndents[i], " \t") != "" || strings.Trim(e.NewIndents[i], " \t") != "" {
+			return fmt.Errorf("EditReindent has non-whitespace indent on line = %d", e.StartLine+i)
+		}
+	}
+	return nil
+}
+
+// Decompose the reindent into a delete of the old indent and an insert of the new indent, on each changed line
+func (e EditReindent) lineEdits() ([]Edit, error) {
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+
+	var edits []Edit
+	for i := range e.OldIndents {
+		if e.OldIndents[i] == e.NewIndents[i] {
+			continue
+		}
+		line := e.StartLine + i
+		if e.OldIndents[i] != "" {
+			end := Position{Line: line, Character: utf8.RuneCountInString(e.OldIndents[i])}
+			edits = append(edits, EditDelete{DeleteText: e.OldIndents[i], DeleteRange: Range{Start: Position{Line: line, Character: 0}, End: end}})
+		}
+		if e.NewIndents[i] != "" {
+			edits = append(edits, EditInsert{NewText: e.NewIndents[i], Position: Position{Line: line, Character: 0}})
+		}
+	}
+	return edits, nil
+}
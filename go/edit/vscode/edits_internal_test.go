@@ -366,13 +366,22 @@ func TestSplitDeletetByLine(t *testing.T) {
 			},
 			false,
 		},
+		"multi line, not ending in \\n": {
+			EditDelete{DeleteText: "123\n456", DeleteRange: Range{Start: Position{Line: 3, Character: 10}, End: Position{Line: 4, Character: 3}}},
+			[]Edit{
+				EditDelete{DeleteText: "123\n", DeleteRange: Range{Start: Position{Line: 3, Character: 10}, End: Position{Line: 4, Character: 0}}},
+				EditDelete{DeleteText: "456", DeleteRange: Range{Start: Position{Line: 3, Character: 10}, End: Position{Line: 3, Character: 13}}},
+			},
+			false,
+		},
 		`consecutive\n`: {
 			EditDelete{DeleteText: "123456\n\n\n789\n", DeleteRange: Range{Start: Position{Line: 3, Character: 10}, End: Position{Line: 7, Character: 0}}},
 			[]Edit{
+				// each line is deleted at the start position, after the preceding lines are deleted
 				EditDelete{DeleteText: "123456\n", DeleteRange: Range{Start: Position{Line: 3, Character: 10}, End: Position{Line: 4, Character: 0}}},
-				EditDelete{DeleteText: "\n", DeleteRange: Range{Start: Position{Line: 4, Character: 0}, End: Position{Line: 5, Character: 0}}},
-				EditDelete{DeleteText: "\n", DeleteRange: Range{Start: Position{Line: 5, Character: 0}, End: Position{Line: 6, Character: 0}}},
-				EditDelete{DeleteText: "789\n", DeleteRange: Range{Start: Position{Line: 6, Character: 0}, End: Position{Line: 7, Character: 0}}},
+				EditDelete{DeleteText: "\n", DeleteRange: Range{Start: Position{Line: 3, Character: 10}, End: Position{Line: 4, Character: 0}}},
+				EditDelete{DeleteText: "\n", DeleteRange: Range{Start: Position{Line: 3, Character: 10}, End: Position{Line: 4, Character: 0}}},
+				EditDelete{DeleteText: "789\n", DeleteRange: Range{Start: Position{Line: 3, Character: 10}, End: Position{Line: 4, Character: 0}}},
 			},
 			false,
 		},
@@ -590,3 +599,31 @@ func TestSplitDeletetByWord(t *testing.T) {
 		})
 	}
 }
+
+// Split deletes are applied one after another, so applying them in order must give the same text as the whole delete
+func TestSplitDeleteAppliedInOrder(t *testing.T) {
+	before := "a\nfoo bar\n\nbaz qux\nb\n"
+	delete := EditDelete{DeleteText: "oo bar\n\nbaz q", DeleteRange: Range{Start: Position{Line: 1, Character: 1}, End: Position{Line: 3, Character: 5}}}
+
+	expected, err := delete.Apply(before)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, strategy := range []SplitStrategy{SplitByLine, SplitByWord, SplitByChar} {
+		edits, err := delete.Split(strategy)
+		if err != nil {
+			t.Fatalf("strategy = %d, unexpected error: %s", strategy, err)
+		}
+
+		result := before
+		for i, e := range edits {
+			if result, err = e.Apply(result); err != nil {
+				t.Fatalf("strategy = %d, edits[%d] = %+v, unexpected error: %s", strategy, i, e, err)
+			}
+		}
+		if result != expected {
+			t.Errorf("strategy = %d, %s", strategy, cmp.Diff(expected, result))
+		}
+	}
+}
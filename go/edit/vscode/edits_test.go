@@ -0,0 +1,311 @@
+package vscode_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestEditMoveApply(t *testing.T) {
+	cases := map[string]struct {
+		before   string
+		move     vscode.EditMove
+		expected string
+		err      bool
+	}{
+		"move line down": {
+			"a\nb\nc\n",
+			vscode.EditMove{MoveText: "a\n", FromRange: vscode.Range{Start: vscode.Position{Line: 0, Character: 0}, End: vscode.Position{Line: 1, Character: 0}}, ToPosition: vscode.Position{Line: 2, Character: 0}},
+			"b\nc\na\n",
+			false,
+		},
+		"move lines up": {
+			"a\nb\nc\nd\n",
+			vscode.EditMove{MoveText: "c\nd\n", FromRange: vscode.Range{Start: vscode.Position{Line: 2, Character: 0}, End: vscode.Position{Line: 4, Character: 0}}, ToPosition: vscode.Position{Line: 0, Character: 0}},
+			"c\nd\na\nb\n",
+			false,
+		},
+		"move word": {
+			"foo bar baz",
+			vscode.EditMove{MoveText: "foo ", FromRange: vscode.Range{Start: vscode.Position{Line: 0, Character: 0}, End: vscode.Position{Line: 0, Character: 4}}, ToPosition: vscode.Position{Line: 0, Character: 4}},
+			"bar foo baz",
+			false,
+		},
+		"ERROR: paste after the end": {
+			"a\nb\n",
+			vscode.EditMove{MoveText: "a\n", FromRange: vscode.Range{Start: vscode.Position{Line: 0, Character: 0}, End: vscode.Position{Line: 1, Character: 0}}, ToPosition: vscode.Position{Line: 5, Character: 0}},
+			"",
+			true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := c.move.Apply(c.before)
+			if err != nil {
+				if c.err {
+					return // expected error
+				}
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if c.err {
+				t.Fatalf("Expected error: but succeeded with result = %s", result)
+			}
+			if c.expected != result {
+				t.Errorf("%s", cmp.Diff(c.expected, result))
+			}
+		})
+	}
+}
+
+func TestEditReindentApply(t *testing.T) {
+	cases := map[string]struct {
+		before   string
+		reindent vscode.EditReindent
+		expected string
+		err      bool
+	}{
+		"indent block": {
+			"a\nb\nc\n",
+			vscode.EditReindent{StartLine: 1, OldIndents: []string{"", ""}, NewIndents: []string{"\t", "\t"}},
+			"a\n\tb\n\tc\n",
+			false,
+		},
+		"outdent and convert": {
+			"    a\n\t\tb\n",
+			vscode.EditReindent{StartLine: 0, OldIndents: []string{"    ", "\t\t"}, NewIndents: []string{"  ", "\t"}},
+			"  a\n\tb\n",
+			false,
+		},
+		"unchanged line in the middle": {
+			"a\n\nb\n",
+			vscode.EditReindent{StartLine: 0, OldIndents: []string{"", "", ""}, NewIndents: []string{"  ", "", "  "}},
+			"  a\n\n  b\n",
+			false,
+		},
+		"ERROR: mismatched lengths": {
+			"a\n",
+			vscode.EditReindent{StartLine: 0, OldIndents: []string{""}, NewIndents: []string{}},
+			"",
+			true,
+		},
+		"ERROR: non-whitespace indent": {
+			"a\n",
+			vscode.EditReindent{StartLine: 0, OldIndents: []string{""}, NewIndents: []string{"x"}},
+			"",
+			true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := c.reindent.Apply(c.before)
+			if err != nil {
+				if c.err {
+					return // expected error
+				}
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if c.err {
+				t.Fatalf("Expected error: but succeeded with result = %s", result)
+			}
+			if c.expected != result {
+				t.Errorf("%s", cmp.Diff(c.expected, result))
+			}
+		})
+	}
+}
+
+func TestEditReplaceSplit(t *testing.T) {
+	before := "foo(bar)\n"
+	replace := vscode.EditReplace{
+		OldText:      "bar",
+		NewText:      "baz qux",
+		ReplaceRange: vscode.Range{Start: vscode.Position{Line: 0, Character: 4}, End: vscode.Position{Line: 0, Character: 7}},
+	}
+
+	cases := map[string]struct {
+		strategy vscode.SplitStrategy
+		steps    int
+	}{
+		"by line": {vscode.SplitByLine, 1},
+		"by word": {vscode.SplitByWord, 2},
+		"by char": {vscode.SplitByChar, 7},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			edits, err := replace.Split(c.strategy)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(edits) != c.steps {
+				t.Errorf("expected %d steps, but got %+v", c.steps, edits)
+			}
+			if _, ok := edits[0].(vscode.EditReplace); !ok {
+				t.Errorf("expected the first step to replace the selection, but got %+v", edits[0])
+			}
+
+			result := before
+			for _, e := range edits {
+				if result, err = e.Apply(result); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			if d := cmp.Diff("foo(baz qux)\n", result); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+}
+
+func TestEditFromMonaco(t *testing.T) {
+	text := "package main\n\nfunc main() {}\n"
+	monacoRange := func(startLine, startColumn, endLine, endColumn int) monaco.Range {
+		return monaco.Range{StartLineNumber: startLine, StartColumn: startColumn, EndLineNumber: endLine, EndColumn: endColumn}
+	}
+
+	cases := map[string]struct {
+		op       monaco.SingleEditOperation
+		expected vscode.Edit
+		err      bool
+	}{
+		"insert": {
+			monaco.SingleEditOperation{Text: "// comment\n", Range: monacoRange(3, 1, 3, 1)},
+			vscode.EditInsert{NewText: "// comment\n", Position: vscode.Position{Line: 2, Character: 0}},
+			false,
+		},
+		"delete": {
+			monaco.SingleEditOperation{Text: "", Range: monacoRange(1, 13, 3, 1)},
+			vscode.EditDelete{DeleteText: "\n\n", DeleteRange: vscode.Range{Start: vscode.Position{Line: 0, Character: 12}, End: vscode.Position{Line: 2, Character: 0}}},
+			false,
+		},
+		"replace": {
+			monaco.SingleEditOperation{Text: "run", Range: monacoRange(3, 6, 3, 10)},
+			vscode.EditReplace{OldText: "main", NewText: "run", ReplaceRange: vscode.Range{Start: vscode.Position{Line: 2, Character: 5}, End: vscode.Position{Line: 2, Character: 9}}},
+			false,
+		},
+		"ERROR: out of range": {
+			monaco.SingleEditOperation{Text: "x", Range: monacoRange(10, 1, 10, 1)},
+			nil,
+			true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := vscode.EditFromMonaco(c.op, text)
+			if err != nil {
+				if c.err {
+					return // expected error
+				}
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if c.err {
+				t.Fatalf("Expected error: but succeeded with result = %+v", result)
+			}
+			if d := cmp.Diff(c.expected, result); d != "" {
+				t.Errorf("%s", d)
+			}
+
+			// Round trip
+			op, err := vscode.EditToMonaco(result)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if op.Text != c.op.Text || op.Range != c.op.Range {
+				t.Errorf("round trip failed, %+v", op)
+			}
+			if _, ok := result.(vscode.EditReplace); ok && op.Operation != "Replace" {
+				t.Errorf("expected operation = Replace, but got %+v", op)
+			}
+		})
+	}
+}
+
+func TestSplitMonacoEdits(t *testing.T) {
+	before := "func a() {\n\treturn nil\n}\n\nfunc b() {}\n"
+	after := "func a(x int) {\n\tif x > 0 {\n\t\treturn nil\n\t}\n}\n"
+
+	ops, err := diff.CalcMonacoEditsWithOptions(before, after, diff.Options{LineMode: true, Refine: diff.RefineWord, Reindent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"line", "word", "char", "token"} {
+		t.Run(name, func(t *testing.T) {
+			strategy, err := vscode.SplitStrategyByName(name)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			split, err := vscode.SplitMonacoEdits(before, ops, strategy)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(split) < len(ops) {
+				t.Errorf("expected at least %d steps, but got %d", len(ops), len(split))
+			}
+
+			// Apply the split operations one by one, same as the frontend
+			result := before
+			for i, op := range split {
+				edit, err := vscode.EditFromMonaco(op, result)
+				if err != nil {
+					t.Fatalf("split[%d] = %+v, %s", i, op, err)
+				}
+				if result, err = edit.Apply(result); err != nil {
+					t.Fatalf("split[%d] = %+v, %s", i, op, err)
+				}
+			}
+			if d := cmp.Diff(after, result); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+
+	if _, err := vscode.SplitStrategyByName("sentence"); err == nil {
+		t.Errorf("Expected error: but succeeded for unknown strategy")
+	}
+}
+
+func TestSplitByToken(t *testing.T) {
+	insert := vscode.EditInsert{NewText: "foo(bar,  baz)\n", Position: vscode.Position{Line: 1, Character: 2}}
+
+	edits, err := insert.Split(vscode.SplitByToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var tokens []string
+	for _, e := range edits {
+		tokens = append(tokens, e.(vscode.EditInsert).NewText)
+	}
+	expected := []string{"\n", "foo", "(", "bar", ",", "  ", "baz", ")"}
+	if d := cmp.Diff(expected, tokens); d != "" {
+		t.Errorf("%s", d)
+	}
+
+	// Delete the same text token by token
+	delete := vscode.EditDelete{DeleteText: "foo(bar,  baz)\n", DeleteRange: vscode.Range{Start: vscode.Position{Line: 1, Character: 2}, End: vscode.Position{Line: 2, Character: 0}}}
+	edits, err = delete.Split(vscode.SplitByToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result := "a\n  foo(bar,  baz)\nb"
+	for _, e := range edits {
+		if result, err = e.Apply(result); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if d := cmp.Diff("a\n  b", result); d != "" {
+		t.Errorf("%s", d)
+	}
+}
@@ -0,0 +1,34 @@
+package vscode
+
+import "fmt"
+
+// Error when the position's line doesn't exist in the document
+type ErrLineOutOfRange struct {
+	Position  Position
+	LineCount int
+}
+
+// Error when the position's character is after the end of the line
+type ErrCharacterOutOfRange struct {
+	Position   Position
+	LineLength int
+}
+
+// Error when the text to delete doesn't match the text actually in the range
+type ErrDeleteTextMismatch struct {
+	Range    Range
+	Expected string
+	Actual   string
+}
+
+func (e *ErrLineOutOfRange) Error() string {
+	return fmt.Sprintf("line = %d in position %+v is out of range, as the document has only %d lines", e.Position.Line, e.Position, e.LineCount)
+}
+
+func (e *ErrCharacterOutOfRange) Error() string {
+	return fmt.Sprintf("character = %d in position %+v is out of range, as line = %d has only %d characters", e.Position.Character, e.Position, e.Position.Line, e.LineLength)
+}
+
+func (e *ErrDeleteTextMismatch) Error() string {
+	return fmt.Sprintf("text to delete = '%s' doesn't match the text = '%s' actually in range %+v", e.Expected, e.Actual, e.Range)
+}
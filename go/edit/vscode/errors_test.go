@@ -0,0 +1,198 @@
+package vscode_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestOutOfRangeErrors(t *testing.T) {
+	text := "abc\nde\n"
+
+	cases := map[string]struct {
+		apply   func() error
+		lineErr *vscode.ErrLineOutOfRange
+		charErr *vscode.ErrCharacterOutOfRange
+	}{
+		"Insert, line": {
+			func() error {
+				_, err := vscode.Insert(strings.NewReader(text), vscode.Position{Line: 5, Character: 0}, "x")
+				return err
+			},
+			&vscode.ErrLineOutOfRange{Position: vscode.Position{Line: 5, Character: 0}, LineCount: 3}, nil,
+		},
+		"Insert, character": {
+			func() error {
+				_, err := vscode.Insert(strings.NewReader(text), vscode.Position{Line: 1, Character: 3}, "x")
+				return err
+			},
+			nil, &vscode.ErrCharacterOutOfRange{Position: vscode.Position{Line: 1, Character: 3}, LineLength: 2},
+		},
+		"Delete, end character": {
+			func() error {
+				_, err := vscode.Delete(strings.NewReader(text), vscode.Range{Start: vscode.Position{Line: 0, Character: 1}, End: vscode.Position{Line: 0, Character: 4}})
+				return err
+			},
+			nil, &vscode.ErrCharacterOutOfRange{Position: vscode.Position{Line: 0, Character: 4}, LineLength: 3},
+		},
+		"Document, line": {
+			func() error {
+				return vscode.NewDocument(text).Insert(vscode.Position{Line: 3, Character: 0}, "x")
+			},
+			&vscode.ErrLineOutOfRange{Position: vscode.Position{Line: 3, Character: 0}, LineCount: 3}, nil,
+		},
+		"Apply, character": {
+			func() error {
+				_, err := vscode.EditInsert{NewText: "x", Position: vscode.Position{Line: 2, Character: 1}}.Apply(text)
+				return err
+			},
+			nil, &vscode.ErrCharacterOutOfRange{Position: vscode.Position{Line: 2, Character: 1}, LineLength: 0},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := c.apply()
+			if err == nil {
+				t.Fatalf("Expected error: but succeeded")
+			}
+
+			var lineErr *vscode.ErrLineOutOfRange
+			if errors.As(err, &lineErr) {
+				if d := cmp.Diff(c.lineErr, lineErr); d != "" {
+					t.Errorf("%s", d)
+				}
+			} else if c.lineErr != nil {
+				t.Errorf("expected ErrLineOutOfRange, but got %s", err)
+			}
+
+			var charErr *vscode.ErrCharacterOutOfRange
+			if errors.As(err, &charErr) {
+				if d := cmp.Diff(c.charErr, charErr); d != "" {
+					t.Errorf("%s", d)
+				}
+			} else if c.charErr != nil {
+				t.Errorf("expected ErrCharacterOutOfRange, but got %s", err)
+			}
+		})
+	}
+}
+
+func TestVerifyDeleteText(t *testing.T) {
+	deleteRange := vscode.Range{Start: vscode.Position{Line: 0, Character: 4}, End: vscode.Position{Line: 0, Character: 7}}
+
+	cases := map[string]struct {
+		edit     vscode.Edit
+		verify   bool
+		mismatch bool
+	}{
+		"matched":                  {vscode.EditDelete{DeleteText: "bar", DeleteRange: deleteRange}, true, false},
+		"mismatched":               {vscode.EditDelete{DeleteText: "baz", DeleteRange: deleteRange}, true, true},
+		"mismatched, not verified": {vscode.EditDelete{DeleteText: "baz", DeleteRange: deleteRange}, false, false},
+		"mismatched replace":       {vscode.EditReplace{OldText: "baz", NewText: "x", ReplaceRange: deleteRange}, true, true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			doc := vscode.NewDocument("foo(bar)\n")
+			doc.VerifyDeleteText = c.verify
+
+			err := doc.Apply(c.edit)
+			var mismatch *vscode.ErrDeleteTextMismatch
+			if errors.As(err, &mismatch) != c.mismatch {
+				t.Fatalf("expected mismatch = %t, but got error = %v", c.mismatch, err)
+			}
+			if c.mismatch && (mismatch.Actual != "bar" || mismatch.Range != deleteRange) {
+				t.Errorf("unexpected error content %+v", mismatch)
+			}
+			if !c.mismatch && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestReindentMismatch(t *testing.T) {
+	// The old indent doesn't match the actual indent on line 1, so applying it would delete "b"
+	reindent := vscode.EditReindent{StartLine: 0, OldIndents: []string{"", "  "}, NewIndents: []string{"\t", "\t"}}
+
+	_, err := reindent.Apply("a\n b\n")
+	var mismatch *vscode.ErrDeleteTextMismatch
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected ErrDeleteTextMismatch, but got error = %v", err)
+	}
+	if mismatch.Expected != "  " || mismatch.Actual != " b" {
+		t.Errorf("unexpected error content %+v", mismatch)
+	}
+}
+
+func TestVerifyDeleteTextOption(t *testing.T) {
+	deleteRange := vscode.Range{Start: vscode.Position{Line: 0, Character: 4}, End: vscode.Position{Line: 0, Character: 7}}
+	stale := vscode.EditDelete{DeleteText: "baz", DeleteRange: deleteRange}
+
+	cases := map[string]struct {
+		apply func(text string, verify bool) error
+	}{
+		"ApplyEditsWithOptions": {
+			func(text string, verify bool) error {
+				_, err := vscode.ApplyEditsWithOptions(text, []vscode.Edit{stale}, vscode.ApplyOptions{VerifyDeleteText: verify})
+				return err
+			},
+		},
+		"EditDelete.ApplyVerified": {
+			func(text string, verify bool) error {
+				var err error
+				if verify {
+					_, err = stale.ApplyVerified(text)
+				} else {
+					_, err = stale.Apply(text)
+				}
+				return err
+			},
+		},
+		"EditDelete.ApplyToFileVerified": {
+			func(text string, verify bool) error {
+				filename := filepath.Join(t.TempDir(), "file.txt")
+				if err := os.WriteFile(filename, []byte(text), 0666); err != nil {
+					t.Fatal(err)
+				}
+				if verify {
+					return stale.ApplyToFileVerified(filename)
+				}
+				return stale.ApplyToFile(filename)
+			},
+		},
+		"ApplyEditsToFile": {
+			func(text string, verify bool) error {
+				filename := filepath.Join(t.TempDir(), "file.txt")
+				if err := os.WriteFile(filename, []byte(text), 0666); err != nil {
+					t.Fatal(err)
+				}
+				return vscode.ApplyEditsToFile(filename, []vscode.Edit{stale}, vscode.FileOptions{VerifyDeleteText: verify})
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// not verified by default, same as EditDelete.Apply
+			if err := c.apply("foo(bar)\n", false); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			err := c.apply("foo(bar)\n", true)
+			var mismatch *vscode.ErrDeleteTextMismatch
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("expected ErrDeleteTextMismatch, but got error = %v", err)
+			}
+			if mismatch.Expected != "baz" || mismatch.Actual != "bar" {
+				t.Errorf("unexpected error content %+v", mismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package vscode
+
+import (
+	"fmt"
+	"os"
+)
+
+type FileOptions struct {
+	// Take an advisory lock on "<filename>.lock" while reading, applying and writing,
+	// to serialize writers taking the same lock. Only supported on unix.
+	//
+	// InsertInFile, DeleteInFile, WriteFile and Edit.ApplyToFile never take the lock, so they still race with locked writers.
+	// Use ApplyEditsToFile with Lock for every writer of the file, to serialize them.
+	Lock bool
+	// Same as ApplyOptions.VerifyDeleteText
+	VerifyDeleteText bool
+}
+
+// Apply the edits to the file in memory, and write the result at once.
+// The result is written to a temp file in the same directory, and renamed over the file, preserving the file mode,
+// so that readers like editors reloading the file never see a partially written file.
+func ApplyEditsToFile(filename string, edits []Edit, opts FileOptions) error {
+	errorPrefix := fmt.Sprintf("ApplyEditsToFile() error in file = '%s'", filename)
+
+	if opts.Lock {
+		unlock, err := lockFile(filename)
+		if err != nil {
+			return fmt.Errorf("%s, %s", errorPrefix, err)
+		}
+		defer unlock()
+	}
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	result, err := ApplyEditsWithOptions(string(contents), edits, ApplyOptions{VerifyDeleteText: opts.VerifyDeleteText})
+	if err != nil {
+		return fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+
+	if err := writeFileAtomic(filename, result); err != nil {
+		return fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	return nil
+}
+
+// Write text to the file atomically, same as ApplyEditsToFile, e.g. to write a whole frame of an animation at once
+func WriteFile(filename string, text string) error {
+	if err := writeFileAtomic(filename, text); err != nil {
+		return fmt.Errorf("WriteFile() error in file = '%s', %s", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package vscode
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Write text to a temp file next to the file, and rename it over the file, preserving the file mode.
+// Both the temp file and the directory are synced, so that either the old or the new contents survive a crash.
+func writeFileAtomic(filename string, text string) (err error) {
+	// Replace the symlink's target, not the symlink itself
+	path, err := filepath.EvalSymlinks(filename)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			temp.Close()
+			os.Remove(temp.Name())
+		}
+	}()
+
+	if _, err = temp.WriteString(text); err != nil {
+		return err
+	}
+	if err = temp.Sync(); err != nil {
+		return err
+	}
+	// Perm() alone drops setuid, setgid and sticky bits
+	if err = temp.Chmod(info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)); err != nil {
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+
+	// The rename is only in the directory entry, which needs its own sync
+	return syncDir(filepath.Dir(path))
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package vscode
+
+import "errors"
+
+func lockFile(filename string) (func(), error) {
+	return nil, errors.New("advisory lock is not supported on this platform")
+}
+
+// Directories cannot be opened for sync on this platform, so the rename is durable only when the OS flushes it
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package vscode_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestApplyEditsToFile(t *testing.T) {
+	pos := func(line, ch int) vscode.Position { return vscode.Position{Line: line, Character: ch} }
+
+	cases := map[string]struct {
+		before   string
+		edits    []vscode.Edit
+		lock     bool
+		expected string
+		err      bool
+	}{
+		"batch": {
+			before: "func a() {\n}\n",
+			edits: []vscode.Edit{
+				vscode.EditInsert{NewText: "\treturn\n", Position: pos(1, 0)},
+				vscode.EditReplace{OldText: "a", NewText: "b", ReplaceRange: vscode.Range{Start: pos(0, 5), End: pos(0, 6)}},
+			},
+			expected: "func b() {\n\treturn\n}\n",
+		},
+		"no edits": {
+			before:   "abc\n",
+			expected: "abc\n",
+		},
+		"lock": {
+			before:   "abc\n",
+			edits:    []vscode.Edit{vscode.EditDelete{DeleteText: "b", DeleteRange: vscode.Range{Start: pos(0, 1), End: pos(0, 2)}}},
+			lock:     runtime.GOOS != "windows" && runtime.GOOS != "plan9",
+			expected: "ac\n",
+		},
+		"ERROR: 2nd edit fails, file untouched": {
+			before: "abc\n",
+			edits: []vscode.Edit{
+				vscode.EditInsert{NewText: "x", Position: pos(0, 0)},
+				vscode.EditInsert{NewText: "y", Position: pos(5, 0)},
+			},
+			expected: "abc\n",
+			err:      true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "file.txt")
+			if err := os.WriteFile(filename, []byte(c.before), 0640); err != nil {
+				t.Fatal(err)
+			}
+
+			err := vscode.ApplyEditsToFile(filename, c.edits, vscode.FileOptions{Lock: c.lock})
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && c.err {
+				t.Fatal("Expected error: but succeeded")
+			}
+
+			contents, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(c.expected, string(contents)); d != "" {
+				t.Errorf("%s", d)
+			}
+
+			// mode is preserved, and no temp file is left behind
+			info, err := os.Stat(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm() != 0640 {
+				t.Errorf("expected mode = 0640, but got %o", info.Mode().Perm())
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if e.Name() != "file.txt" && e.Name() != "file.txt.lock" {
+					t.Errorf("unexpected file = %s", e.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestApplyEditsToFileTypedError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(filename, []byte("abc\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	edits := []vscode.Edit{vscode.EditInsert{NewText: "x", Position: vscode.Position{Line: 3, Character: 0}}}
+	err := vscode.ApplyEditsToFile(filename, edits, vscode.FileOptions{})
+
+	var lineErr *vscode.ErrLineOutOfRange
+	if !errors.As(err, &lineErr) {
+		t.Errorf("expected *ErrLineOutOfRange, but got %v", err)
+	}
+}
+
+func TestApplyEditsToFileKeepsModeBits(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("setgid is not supported on " + runtime.GOOS)
+	}
+
+	filename := filepath.Join(t.TempDir(), "script.sh")
+	if err := os.WriteFile(filename, []byte("echo a\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mode := 0755 | os.ModeSetgid
+	if err := os.Chmod(filename, mode); err != nil {
+		t.Fatal(err)
+	}
+
+	edits := []vscode.Edit{vscode.EditInsert{NewText: "b", Position: vscode.Position{Line: 0, Character: 6}}}
+	if err := vscode.ApplyEditsToFile(filename, edits, vscode.FileOptions{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode() != mode {
+		t.Errorf("expected mode = %s, but got %s", mode, info.Mode())
+	}
+}
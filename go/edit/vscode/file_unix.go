@@ -0,0 +1,38 @@
+//go:build unix
+
+package vscode
+
+import (
+	"os"
+	"syscall"
+)
+
+// Take an exclusive advisory lock on "<filename>.lock", which is kept after unlock,
+// since the file itself is replaced by rename and cannot hold the lock
+func lockFile(filename string) (func(), error) {
+	lock, err := os.OpenFile(filename+".lock", os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	unlock := func() {
+		syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+		lock.Close()
+	}
+	return unlock, nil
+}
+
+// Sync the directory, so that the rename of a file in it survives a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,112 @@
+package vscode
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Value of "editType" in JSON, which tells the concrete edit type
+const (
+	EditTypeInsert   = "insert"
+	EditTypeDelete   = "delete"
+	EditTypeReplace  = "replace"
+	EditTypeMove     = "move"
+	EditTypeReindent = "reindent"
+)
+
+// Edits marshals to, and unmarshals from, a JSON array of tagged edits, where each edit has the fields of the concrete type
+// and "editType" telling the type:
+//
+//	[{"editType": "insert", "newText": "abc", "position": {"line": 0, "character": 0}}]
+type Edits []Edit
+
+func (edits Edits) MarshalJSON() ([]byte, error) {
+	tagged := make([]json.RawMessage, 0, len(edits))
+	for i, e := range edits {
+		data, err := MarshalEdit(e)
+		if err != nil {
+			return nil, fmt.Errorf("edits[%d], %w", i, err)
+		}
+		tagged = append(tagged, data)
+	}
+	return json.Marshal(tagged)
+}
+
+func (edits *Edits) UnmarshalJSON(data []byte) error {
+	var tagged []json.RawMessage
+	if err := json.Unmarshal(data, &tagged); err != nil {
+		return err
+	}
+
+	result := make(Edits, 0, len(tagged))
+	for i, t := range tagged {
+		e, err := UnmarshalEdit(t)
+		if err != nil {
+			return fmt.Errorf("edits[%d], %w", i, err)
+		}
+		result = append(result, e)
+	}
+	*edits = result
+
+	return nil
+}
+
+// Marshal a single edit to JSON with "editType"
+func MarshalEdit(e Edit) ([]byte, error) {
+	switch v := e.(type) {
+	case EditInsert:
+		return json.Marshal(struct {
+			EditType string `json:"editType"`
+			EditInsert
+		}{EditTypeInsert, v})
+	case EditDelete:
+		return json.Marshal(struct {
+			EditType string `json:"editType"`
+			EditDelete
+		}{EditTypeDelete, v})
+	case EditReplace:
+		return json.Marshal(struct {
+			EditType string `json:"editType"`
+			EditReplace
+		}{EditTypeReplace, v})
+	case EditMove:
+		return json.Marshal(struct {
+			EditType string `json:"editType"`
+			EditMove
+		}{EditTypeMove, v})
+	case EditReindent:
+		return json.Marshal(struct {
+			EditType string `json:"editType"`
+			EditReindent
+		}{EditTypeReindent, v})
+	default:
+		return nil, fmt.Errorf("cannot marshal edit of unknown type = %T", e)
+	}
+}
+
+// Unmarshal a single edit from JSON with "editType"
+func UnmarshalEdit(data []byte) (Edit, error) {
+	var tag struct {
+		EditType string `json:"editType"`
+	}
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return nil, err
+	}
+
+	switch tag.EditType {
+	case EditTypeInsert:
+		return unmarshalAs[EditInsert](data)
+	case EditTypeDelete:
+		return unmarshalAs[EditDelete](data)
+	case EditTypeReplace:
+		return unmarshalAs[EditReplace](data)
+	case EditTypeMove:
+		return unmarshalAs[EditMove](data)
+	case EditTypeReindent:
+		return unmarshalAs[EditReindent](data)
+	case "":
+		return nil, fmt.Errorf("editType is missing in %s", data)
+	default:
+		return nil, fmt.Errorf("unknown editType = '%s'", tag.EditType)
+	}
+}
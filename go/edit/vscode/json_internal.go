@@ -0,0 +1,11 @@
+package vscode
+
+import "encoding/json"
+
+func unmarshalAs[T Edit](data []byte) (Edit, error) {
+	var e T
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,64 @@
+package vscode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestEditsJSON(t *testing.T) {
+	edits := vscode.Edits{
+		vscode.EditInsert{NewText: "abc", Position: pos(0, 1)},
+		vscode.EditDelete{DeleteText: "de", DeleteRange: vscode.Range{Start: pos(1, 0), End: pos(1, 2)}},
+		vscode.EditReplace{OldText: "x", NewText: "y", ReplaceRange: vscode.Range{Start: pos(2, 0), End: pos(2, 1)}},
+		vscode.EditMove{MoveText: "line\n", FromRange: vscode.Range{Start: pos(3, 0), End: pos(4, 0)}, ToPosition: pos(0, 0)},
+		vscode.EditReindent{StartLine: 5, OldIndents: []string{"", "\t"}, NewIndents: []string{"\t", "\t\t"}},
+	}
+
+	data, err := json.Marshal(edits)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var result vscode.Edits
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d := cmp.Diff(edits, result); d != "" {
+		t.Errorf("%s", d)
+	}
+
+	// field names are the same as VS Code's
+	expected := `{"editType":"insert","newText":"abc","position":{"line":0,"character":1}}`
+	first, err := vscode.MarshalEdit(edits[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(first) != expected {
+		t.Errorf("%s", cmp.Diff(expected, string(first)))
+	}
+}
+
+func TestUnmarshalEditError(t *testing.T) {
+	cases := map[string]string{
+		"ERROR: missing editType": `{"newText":"abc"}`,
+		"ERROR: unknown editType": `{"editType":"paste","newText":"abc"}`,
+		"ERROR: wrong field type": `{"editType":"insert","newText":1}`,
+		"ERROR: not an object":    `"insert"`,
+	}
+
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := vscode.UnmarshalEdit([]byte(data))
+			if err == nil {
+				t.Fatalf("Expected error: but succeeded with result = %+v", result)
+			}
+		})
+	}
+
+	if _, err := json.Marshal(vscode.Edits{nil}); err == nil {
+		t.Errorf("Expected error: but succeeded to marshal nil edit")
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"syscall"
+	"strings"
 )
 
 func Insert(reader io.Reader, position Position, newText string) (string, error) {
@@ -15,8 +15,14 @@ func Insert(reader io.Reader, position Position, newText string) (string, error)
 		return "", fmt.Errorf("%s, %s", errorPrefix, err)
 	}
 
+	// 2. Validate against the actual document
+	text, err := readAndValidate(reader, position)
+	if err != nil {
+		return "", fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+
 	// 3. Internal logic
-	result, err := insertInternal(reader, position, newText)
+	result, err := insertInternal(strings.NewReader(text), position, newText)
 	if err != nil {
 		return "", fmt.Errorf("%s, %s", errorPrefix, err)
 	}
@@ -32,8 +38,14 @@ func Delete(reader io.Reader, delRange Range) (string, error) {
 		return "", fmt.Errorf("%s, %s", errorPrefix, err)
 	}
 
-	// 2. Internal logic
-	result, err := deleteInternal(reader, delRange)
+	// 2. Validate against the actual document
+	text, err := readAndValidate(reader, delRange.Start, delRange.End)
+	if err != nil {
+		return "", fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+
+	// 3. Internal logic
+	result, err := deleteInternal(strings.NewReader(text), delRange)
 	if err != nil {
 		return "", fmt.Errorf("%s, %s", errorPrefix, err)
 	}
@@ -41,6 +53,7 @@ func Delete(reader io.Reader, delRange Range) (string, error) {
 	return result, nil
 }
 
+// Write the result atomically, same as ApplyEditsToFile, but without the advisory lock of FileOptions.Lock
 func InsertInFile(filename string, position Position, newText string) error {
 	errorPrefix := fmt.Errorf("InsertInFile() error in file = '%s'", filename)
 	// 1. Validate arguments
@@ -49,26 +62,33 @@ func InsertInFile(filename string, position Position, newText string) error {
 	}
 
 	// 2. Open file
-	file, err := os.OpenFile(filename, syscall.O_RDWR, 0666)
+	file, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("%s, %s", errorPrefix, err)
 	}
 	defer file.Close()
 
-	// 3. Internal logic
-	result, err := insertInternal(file, position, newText)
+	// 3. Validate against the actual file contents
+	text, err := readAndValidate(file, position)
+	if err != nil {
+		return fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+
+	// 4. Internal logic
+	result, err := insertInternal(strings.NewReader(text), position, newText)
 	if err != nil {
 		return fmt.Errorf("%s, %s", errorPrefix, err)
 	}
 
-	// 4. Write to file
-	if err := writeFromBeginning(file, result); err != nil {
-		return err
+	// 5. Write to file, atomically replacing the file
+	if err := writeFileAtomic(filename, result); err != nil {
+		return fmt.Errorf("%s, %s", errorPrefix, err)
 	}
 
 	return nil
 }
 
+// Write the result atomically, same as ApplyEditsToFile, but without the advisory lock of FileOptions.Lock
 func DeleteInFile(filename string, delRange Range) error {
 	errorPrefix := fmt.Errorf("DeleteInFile() error in file = '%s'", filename)
 
@@ -78,26 +98,27 @@ func DeleteInFile(filename string, delRange Range) error {
 	}
 
 	// 2. Open file
-	file, err := os.OpenFile(filename, syscall.O_RDWR, 0666)
+	file, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("%s, %s", errorPrefix, err)
 	}
 	defer file.Close()
 
-	// 3. Internal logic
-	result, err := deleteInternal(file, delRange)
+	// 3. Validate against the actual file contents
+	text, err := readAndValidate(file, delRange.Start, delRange.End)
 	if err != nil {
-		return fmt.Errorf("%s, %s", errorPrefix, err)
+		return fmt.Errorf("%s, %w", errorPrefix, err)
 	}
 
-	// 4. Write to file
-	//   os.Truncate() is needed because the deletion makes file shorter,
-	//   otherwise, there will be a residual contents at the end of the file
-	if err := os.Truncate(file.Name(), 0); err != nil {
-		return err
+	// 4. Internal logic
+	result, err := deleteInternal(strings.NewReader(text), delRange)
+	if err != nil {
+		return fmt.Errorf("%s, %s", errorPrefix, err)
 	}
-	if err := writeFromBeginning(file, result); err != nil {
-		return err
+
+	// 5. Write to file, atomically replacing the file
+	if err := writeFileAtomic(filename, result); err != nil {
+		return fmt.Errorf("%s, %s", errorPrefix, err)
 	}
 
 	return nil
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 	"unicode/utf8"
 )
@@ -276,14 +275,22 @@ func copyUntilEOF(fromReader *bufio.Reader, toBuilder *strings.Builder) error {
 	return nil
 }
 
-func writeFromBeginning(file *os.File, text string) error {
-	n, err := file.WriteAt([]byte(text), 0)
-	if n == 0 {
-		return fmt.Errorf("no byte is written to file")
+// Read the whole text from reader, and validate the positions against the lines of the text
+func readAndValidate(reader io.Reader, positions ...Position) (string, error) {
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	text := string(bytes)
+
+	index := NewLineIndex(text)
+	for _, pos := range positions {
+		if err := index.ValidatePosition(pos); err != nil {
+			return "", err
+		}
 	}
 
-	// return err on error, nil on success
-	return err
+	return text, nil
 }
 
 func insertInternal(reader io.Reader, position Position, newText string) (string, error) {
@@ -0,0 +1,162 @@
+package vscode
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Unit of offsets in the text
+type OffsetUnit int
+
+const (
+	// Bytes in UTF-8, as in Go strings, go/token and tree-sitter
+	UnitByte OffsetUnit = 0
+	// Runes, i.e. Unicode code points, same as Position.Character
+	UnitRune OffsetUnit = 1
+	// UTF-16 code units, as in JavaScript strings, LSP and Monaco's model offsets
+	UnitUTF16 OffsetUnit = 2
+)
+
+// Line index of an immutable text, to convert between offsets and positions,
+// and to validate positions against the actual lines of the text.
+//
+// Position.Character is counted in runes, regardless of the offset unit.
+type LineIndex struct {
+	text string
+	// starts[unit][line] = offset of the line start in the unit, followed by the text length in the unit
+	starts [3][]int
+}
+
+func NewLineIndex(text string) *LineIndex {
+	x := &LineIndex{text: text}
+	for unit := range x.starts {
+		x.starts[unit] = []int{0}
+	}
+
+	runes, utf16 := 0, 0
+	for i, r := range text {
+		runes++
+		utf16 += utf16Len(r)
+		if r == '\n' {
+			x.appendStarts(i+1, runes, utf16)
+		}
+	}
+	x.appendStarts(len(text), runes, utf16)
+
+	return x
+}
+
+func (x *LineIndex) LineCount() int {
+	return len(x.starts[UnitByte]) - 1
+}
+
+// Number of characters (= runes) in the line, excluding the trailing '\n'
+func (x *LineIndex) LineLength(line int) (int, error) {
+	if line < 0 || x.LineCount() <= line {
+		return 0, fmt.Errorf("line = %d is out of range, as there are only %d lines", line, x.LineCount())
+	}
+	return x.lineLength(line), nil
+}
+
+// Convert the offset in the unit to the position
+func (x *LineIndex) PositionAt(offset int, unit OffsetUnit) (Position, error) {
+	errorPrefix := "LineIndex.PositionAt() error"
+
+	starts, err := x.unitStarts(unit)
+	if err != nil {
+		return Position{}, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+	if length := starts[len(starts)-1]; offset < 0 || length < offset {
+		return Position{}, fmt.Errorf("%s, offset = %d is out of range [0, %d]", errorPrefix, offset, length)
+	}
+
+	// The last line whose start is <= offset
+	line := sort.Search(x.LineCount(), func(i int) bool { return starts[i] > offset }) - 1
+
+	current, character := starts[line], 0
+	text := x.lineText(line)
+	for i := 0; i < len(text) && current < offset; character++ {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		current += unitLen(r, size, unit)
+		i += size
+	}
+	if current != offset {
+		return Position{}, fmt.Errorf("%s, offset = %d is in the middle of a character", errorPrefix, offset)
+	}
+
+	return Position{Line: line, Character: character}, nil
+}
+
+// Convert the position to the offset in the unit
+func (x *LineIndex) OffsetAt(pos Position, unit OffsetUnit) (int, error) {
+	errorPrefix := "LineIndex.OffsetAt() error"
+
+	starts, err := x.unitStarts(unit)
+	if err != nil {
+		return 0, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+	if err := x.ValidatePosition(pos); err != nil {
+		return 0, fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+
+	offset := starts[pos.Line]
+	text := x.lineText(pos.Line)
+	for i, character := 0, 0; character < pos.Character; character++ {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		offset += unitLen(r, size, unit)
+		i += size
+	}
+
+	return offset, nil
+}
+
+// Validate the position against the actual lines, not only its non-negativity like Position.Validate
+func (x *LineIndex) ValidatePosition(pos Position) error {
+	if err := pos.Validate(); err != nil {
+		return err
+	}
+	if x.LineCount() <= pos.Line {
+		return &ErrLineOutOfRange{Position: pos, LineCount: x.LineCount()}
+	}
+	if length := x.lineLength(pos.Line); length < pos.Character {
+		return &ErrCharacterOutOfRange{Position: pos, LineLength: length}
+	}
+	return nil
+}
+
+func (x *LineIndex) ValidateRange(r Range) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if err := x.ValidatePosition(r.Start); err != nil {
+		return fmt.Errorf("range start error, %w", err)
+	}
+	if err := x.ValidatePosition(r.End); err != nil {
+		return fmt.Errorf("range end error, %w", err)
+	}
+	return nil
+}
+
+// Clamp the position into the text, same as VS Code's TextDocument.validatePosition.
+// A line before the first line becomes the text start, and a line after the last line becomes the text end.
+func (x *LineIndex) ClampPosition(pos Position) Position {
+	lastLine := x.LineCount() - 1
+	switch {
+	case pos.Line < 0:
+		return Position{Line: 0, Character: 0}
+	case pos.Line > lastLine:
+		return Position{Line: lastLine, Character: x.lineLength(lastLine)}
+	default:
+		return Position{Line: pos.Line, Character: min(max(pos.Character, 0), x.lineLength(pos.Line))}
+	}
+}
+
+// Clamp the range into the text, same as VS Code's TextDocument.validateRange, which also swaps reversed start and end
+func (x *LineIndex) ClampRange(r Range) Range {
+	start, end := x.ClampPosition(r.Start), x.ClampPosition(r.End)
+	if !start.LessThanOrEqualTo(end) {
+		start, end = end, start
+	}
+	return Range{Start: start, End: end}
+}
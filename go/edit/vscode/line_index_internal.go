@@ -0,0 +1,48 @@
+package vscode
+
+import "fmt"
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2 // surrogate pair
+	}
+	return 1
+}
+
+// Length of the rune in the unit, where size is the rune's length in bytes
+func unitLen(r rune, size int, unit OffsetUnit) int {
+	switch unit {
+	case UnitRune:
+		return 1
+	case UnitUTF16:
+		return utf16Len(r)
+	default:
+		return size
+	}
+}
+
+func (x *LineIndex) appendStarts(bytes, runes, utf16 int) {
+	x.starts[UnitByte] = append(x.starts[UnitByte], bytes)
+	x.starts[UnitRune] = append(x.starts[UnitRune], runes)
+	x.starts[UnitUTF16] = append(x.starts[UnitUTF16], utf16)
+}
+
+func (x *LineIndex) unitStarts(unit OffsetUnit) ([]int, error) {
+	if unit < UnitByte || UnitUTF16 < unit {
+		return nil, fmt.Errorf("unknown offset unit = %d", unit)
+	}
+	return x.starts[unit], nil
+}
+
+// Text of the line, including the trailing '\n' if any
+func (x *LineIndex) lineText(line int) string {
+	return x.text[x.starts[UnitByte][line]:x.starts[UnitByte][line+1]]
+}
+
+func (x *LineIndex) lineLength(line int) int {
+	length := x.starts[UnitRune][line+1] - x.starts[UnitRune][line]
+	if line < x.LineCount()-1 {
+		length-- // '\n'
+	}
+	return length
+}
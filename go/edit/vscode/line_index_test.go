@@ -0,0 +1,157 @@
+package vscode_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestLineIndexConversion(t *testing.T) {
+	// "😀" is 4 bytes in UTF-8, and 2 code units in UTF-16
+	x := vscode.NewLineIndex("ab\n日本\n😀x\n")
+
+	cases := map[string]struct {
+		pos    vscode.Position
+		bytes  int
+		runes  int
+		utf16s int
+	}{
+		"beginning":         {vscode.Position{Line: 0, Character: 0}, 0, 0, 0},
+		"end of 1st line":   {vscode.Position{Line: 0, Character: 2}, 2, 2, 2},
+		"Japanese":          {vscode.Position{Line: 1, Character: 1}, 6, 4, 4},
+		"after emoji":       {vscode.Position{Line: 2, Character: 1}, 14, 7, 8},
+		"end of emoji line": {vscode.Position{Line: 2, Character: 2}, 15, 8, 9},
+		"last empty line":   {vscode.Position{Line: 3, Character: 0}, 16, 9, 10},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			units := map[vscode.OffsetUnit]int{vscode.UnitByte: c.bytes, vscode.UnitRune: c.runes, vscode.UnitUTF16: c.utf16s}
+			for unit, expected := range units {
+				offset, err := x.OffsetAt(c.pos, unit)
+				if err != nil {
+					t.Fatalf("unit = %d, unexpected error: %s", unit, err)
+				}
+				if expected != offset {
+					t.Errorf("unit = %d, expected offset = %d, but got %d", unit, expected, offset)
+				}
+
+				pos, err := x.PositionAt(offset, unit)
+				if err != nil {
+					t.Fatalf("unit = %d, unexpected error: %s", unit, err)
+				}
+				if d := cmp.Diff(c.pos, pos); d != "" {
+					t.Errorf("unit = %d, %s", unit, d)
+				}
+			}
+		})
+	}
+}
+
+func TestLineIndexErrors(t *testing.T) {
+	x := vscode.NewLineIndex("ab\n😀\n")
+
+	offsetCases := map[string]struct {
+		offset int
+		unit   vscode.OffsetUnit
+	}{
+		"negative":               {-1, vscode.UnitByte},
+		"after end":              {9, vscode.UnitByte},
+		"in the middle of UTF-8": {4, vscode.UnitByte},
+		"between surrogates":     {4, vscode.UnitUTF16},
+		"unknown unit":           {0, vscode.OffsetUnit(10)},
+	}
+	for name, c := range offsetCases {
+		t.Run(name, func(t *testing.T) {
+			if pos, err := x.PositionAt(c.offset, c.unit); err == nil {
+				t.Errorf("Expected error: but succeeded with result = %+v", pos)
+			}
+		})
+	}
+
+	positionCases := map[string]vscode.Position{
+		"negative character": {Line: 0, Character: -1},
+		"after end of line":  {Line: 0, Character: 3},
+		"after last line":    {Line: 3, Character: 0},
+	}
+	for name, pos := range positionCases {
+		t.Run(name, func(t *testing.T) {
+			if err := x.ValidatePosition(pos); err == nil {
+				t.Errorf("Expected error: but succeeded")
+			}
+			if offset, err := x.OffsetAt(pos, vscode.UnitByte); err == nil {
+				t.Errorf("Expected error: but succeeded with result = %d", offset)
+			}
+		})
+	}
+}
+
+func TestLineIndexClamp(t *testing.T) {
+	x := vscode.NewLineIndex("abc\nde")
+
+	cases := map[string]struct {
+		pos      vscode.Position
+		expected vscode.Position
+	}{
+		"valid":              {vscode.Position{Line: 1, Character: 1}, vscode.Position{Line: 1, Character: 1}},
+		"negative line":      {vscode.Position{Line: -1, Character: 2}, vscode.Position{Line: 0, Character: 0}},
+		"negative character": {vscode.Position{Line: 1, Character: -3}, vscode.Position{Line: 1, Character: 0}},
+		"after end of line":  {vscode.Position{Line: 0, Character: 10}, vscode.Position{Line: 0, Character: 3}},
+		"after last line":    {vscode.Position{Line: 5, Character: 0}, vscode.Position{Line: 1, Character: 2}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result := x.ClampPosition(c.pos)
+			if d := cmp.Diff(c.expected, result); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+
+	reversed := vscode.Range{Start: vscode.Position{Line: 9, Character: 0}, End: vscode.Position{Line: 0, Character: 1}}
+	expected := vscode.Range{Start: vscode.Position{Line: 0, Character: 1}, End: vscode.Position{Line: 1, Character: 2}}
+	if d := cmp.Diff(expected, x.ClampRange(reversed)); d != "" {
+		t.Errorf("%s", d)
+	}
+}
+
+// Offsets in any unit round-trip through positions, for every rune boundary
+func TestLineIndexRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	alphabet := []rune("a\n日😀�")
+
+	for i := 0; i < 200; i++ {
+		runes := make([]rune, r.Intn(30))
+		for k := range runes {
+			runes[k] = alphabet[r.Intn(len(alphabet))]
+		}
+		x := vscode.NewLineIndex(string(runes))
+
+		for offset := 0; offset <= len(runes); offset++ {
+			pos, err := x.PositionAt(offset, vscode.UnitRune)
+			if err != nil {
+				t.Fatalf("text = %q, offset = %d, %s", string(runes), offset, err)
+			}
+			if expected := positionAt(runes, offset); expected != pos {
+				t.Fatalf("text = %q, offset = %d, expected %+v, but got %+v", string(runes), offset, expected, pos)
+			}
+
+			for _, unit := range []vscode.OffsetUnit{vscode.UnitByte, vscode.UnitUTF16} {
+				unitOffset, err := x.OffsetAt(pos, unit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				back, err := x.PositionAt(unitOffset, unit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pos != back {
+					t.Fatalf("text = %q, unit = %d, expected %+v, but got %+v", string(runes), unit, pos, back)
+				}
+			}
+		}
+	}
+}
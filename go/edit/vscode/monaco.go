@@ -0,0 +1,126 @@
+package vscode
+
+import (
+	"fmt"
+
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+)
+
+func (e EditInsert) MonacoEdit() monaco.SingleEditOperation {
+	return monaco.SingleEditOperation{
+		Text:      e.NewText,
+		Range:     toMonacoRange(e.Position, e.Position),
+		Operation: "Insert",
+	}
+}
+
+func (e EditDelete) MonacoEdit() monaco.SingleEditOperation {
+	return monaco.SingleEditOperation{
+		Text:      "", /*empty text for delete*/
+		Range:     toMonacoRange(e.DeleteRange.Start, e.DeleteRange.End),
+		Operation: "Delete",
+	}
+}
+
+// Monaco's edit operation is natively a replace, so EditReplace becomes a single "Replace" operation
+func (e EditReplace) MonacoEdit() monaco.SingleEditOperation {
+	return monaco.SingleEditOperation{
+		Text:      e.NewText,
+		Range:     toMonacoRange(e.ReplaceRange.Start, e.ReplaceRange.End),
+		Operation: "Replace",
+	}
+}
+
+// Convert Monaco's edit operation into EditInsert, EditDelete or EditReplace.
+// text is the model's text before the operation, from which the replaced text is read,
+// as Monaco's operation only has the range.
+func EditFromMonaco(op monaco.SingleEditOperation, text string) (Edit, error) {
+	errorPrefix := "vscode.EditFromMonaco failed"
+
+	r := fromMonacoRange(op.Range)
+	if err := r.Validate(); err != nil {
+		return nil, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	oldText, err := textInRange(text, r)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	switch {
+	case oldText == "":
+		return EditInsert{NewText: op.Text, Position: r.Start}, nil
+	case op.Text == "":
+		return EditDelete{DeleteText: oldText, DeleteRange: r}, nil
+	default:
+		return EditReplace{OldText: oldText, NewText: op.Text, ReplaceRange: r}, nil
+	}
+}
+
+// Convert EditInsert, EditDelete or EditReplace into Monaco's edit operation, the reverse of EditFromMonaco.
+// Other edits return an error, as they are not a single operation.
+func EditToMonaco(edit Edit) (monaco.SingleEditOperation, error) {
+	errorPrefix := "vscode.EditToMonaco failed"
+
+	op, err := monacoEdit(edit)
+	if err != nil {
+		return monaco.SingleEditOperation{}, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	return op, nil
+}
+
+// Split sequential Monaco operations by the strategy, same as Edit.Split, so that the frontend can animate them step by step.
+// before is the model's text before the operations.
+// Halves of a move (non-zero MoveID) and reindent operations are kept as they are, since each of them is a single step.
+// This lives in vscode rather than monaco, as it uses Edit.Split and vscode already imports monaco.
+func SplitMonacoEdits(before string, ops []monaco.SingleEditOperation, strategy SplitStrategy) ([]monaco.SingleEditOperation, error) {
+	errorPrefix := "vscode.SplitMonacoEdits failed"
+
+	doc := NewDocument(before)
+	result := []monaco.SingleEditOperation{}
+	for i, op := range ops {
+		edit, err := EditFromMonaco(op, doc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s, ops[%d], %w", errorPrefix, i, err)
+		}
+		if err := doc.Apply(edit); err != nil {
+			return nil, fmt.Errorf("%s, ops[%d], %w", errorPrefix, i, err)
+		}
+
+		if op.MoveID != 0 || op.Operation == "Reindent" {
+			result = append(result, op)
+			continue
+		}
+
+		split, err := edit.Split(strategy)
+		if err != nil {
+			return nil, fmt.Errorf("%s, ops[%d], %s", errorPrefix, i, err)
+		}
+		for _, e := range split {
+			converted, err := monacoEdit(e)
+			if err != nil {
+				return nil, fmt.Errorf("%s, ops[%d], %s", errorPrefix, i, err)
+			}
+			result = append(result, converted)
+		}
+	}
+
+	return result, nil
+}
+
+// Get the split strategy from its name, "line", "word", "char" or "token"
+func SplitStrategyByName(name string) (SplitStrategy, error) {
+	switch name {
+	case "line":
+		return SplitByLine, nil
+	case "word":
+		return SplitByWord, nil
+	case "char":
+		return SplitByChar, nil
+	case "token":
+		return SplitByToken, nil
+	default:
+		return 0, fmt.Errorf("unknown split strategy = '%s'", name)
+	}
+}
@@ -8,8 +8,8 @@ import (
 // Same as VS Code extention API's Position
 // https://code.visualstudio.com/api/references/vscode-api#Position
 type Position struct {
-	Line      int //The zero-based line value.
-	Character int //The zero-based character value.
+	Line      int `json:"line"`      //The zero-based line value.
+	Character int `json:"character"` //The zero-based character value.
 }
 
 func (p Position) Validate() error {
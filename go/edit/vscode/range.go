@@ -9,8 +9,8 @@ import (
 // Same as VS Code extention API's Position
 // https://code.visualstudio.com/api/references/vscode-api#Range
 type Range struct {
-	Start Position
-	End   Position
+	Start Position `json:"start"`
+	End   Position `json:"end"`
 }
 
 func (r Range) Validate() error {
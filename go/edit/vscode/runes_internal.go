@@ -2,6 +2,7 @@ package vscode
 
 import (
 	"errors"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -38,3 +39,8 @@ func countRunesInLine(lineString string) (int, error) {
 
 	return runeCount, nil
 }
+
+// Split line after each ' ', e.g. "a := b" -> "a ", ":= ", "b"
+func splitWords(line string) []string {
+	return strings.SplitAfter(line, " ")
+}
@@ -0,0 +1,69 @@
+package vscode
+
+import "fmt"
+
+// Tie-breaking between a position (or an insert) and another insert at the same position
+type Bias int
+
+const (
+	// Stay before the text inserted at the same position
+	BiasLeft Bias = 0
+	// Move after the text inserted at the same position
+	BiasRight Bias = 1
+)
+
+func (b Bias) opposite() Bias {
+	if b == BiasLeft {
+		return BiasRight
+	}
+	return BiasLeft
+}
+
+// Transform the position through the sequence of edits, so that it points to the same place in the text after the edits.
+// A position inside a deleted range collapses to the start of the range.
+func TransformPosition(p Position, bias Bias, through ...Edit) (Position, error) {
+	errorPrefix := "vscode.TransformPosition failed"
+
+	for i, t := range through {
+		var err error
+		if p, err = transformPosition(p, t, bias); err != nil {
+			return Position{}, fmt.Errorf("%s, through edit[%d], %s", errorPrefix, i, err)
+		}
+	}
+
+	return p, nil
+}
+
+// Transform the range through the sequence of edits.
+// A non-empty range never grows by text inserted at its start or end, while an empty range follows bias.
+func TransformRange(r Range, bias Bias, through ...Edit) (Range, error) {
+	errorPrefix := "vscode.TransformRange failed"
+
+	for i, t := range through {
+		var err error
+		if r, err = transformRange(r, t, bias); err != nil {
+			return Range{}, fmt.Errorf("%s, through edit[%d], %s", errorPrefix, i, err)
+		}
+	}
+
+	return r, nil
+}
+
+// Transform the sequence of edits through another sequence of edits, where both are concurrent, i.e. made to the same text.
+// The result applies to the text after through, and has the same effect as edits, without undoing any of through.
+//
+// bias decides which inserts go first at the same position: BiasLeft puts the inserts in edits before the ones in through.
+// For concurrent a and b, applying a then TransformEdits(b, BiasRight, a...) results in the same text as
+// applying b then TransformEdits(a, BiasLeft, b...).
+//
+// Only EditInsert and EditDelete are supported.
+func TransformEdits(edits []Edit, bias Bias, through ...Edit) ([]Edit, error) {
+	errorPrefix := "vscode.TransformEdits failed"
+
+	result, _, err := transformSequences(edits, through, bias)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	return result, nil
+}
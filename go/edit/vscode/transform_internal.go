@@ -0,0 +1,246 @@
+package vscode
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+func lessThan(p, target Position) bool {
+	return p != target && p.LessThanOrEqualTo(target)
+}
+
+// Shift p located after the insert from start, which ends at end
+func shiftAfterInsert(p, start, end Position) Position {
+	if p.Line == start.Line {
+		return Position{Line: end.Line, Character: end.Character + p.Character - start.Character}
+	}
+	return Position{Line: p.Line + end.Line - start.Line, Character: p.Character}
+}
+
+// Shift p located after the deleted range
+func shiftAfterDelete(p Position, deleted Range) Position {
+	if p.Line == deleted.End.Line {
+		return Position{Line: deleted.Start.Line, Character: deleted.Start.Character + p.Character - deleted.End.Character}
+	}
+	return Position{Line: p.Line - (deleted.End.Line - deleted.Start.Line), Character: p.Character}
+}
+
+func transformPosition(p Position, through Edit, bias Bias) (Position, error) {
+	switch t := through.(type) {
+	case EditInsert:
+		if t.NewText == "" || lessThan(p, t.Position) || (p == t.Position && bias == BiasLeft) {
+			return p, nil
+		}
+		end, err := editRangeEnd(t.Position, t.NewText)
+		if err != nil {
+			return Position{}, err
+		}
+		return shiftAfterInsert(p, t.Position, end), nil
+
+	case EditDelete:
+		if p.LessThanOrEqualTo(t.DeleteRange.Start) {
+			return p, nil
+		} else if lessThan(p, t.DeleteRange.End) {
+			return t.DeleteRange.Start, nil
+		}
+		return shiftAfterDelete(p, t.DeleteRange), nil
+
+	default:
+		return Position{}, fmt.Errorf("cannot transform through edit type %T", through)
+	}
+}
+
+func transformRange(r Range, through Edit, bias Bias) (Range, error) {
+	startBias, endBias := BiasRight, BiasLeft
+	if r.Start == r.End {
+		startBias, endBias = bias, bias
+	}
+
+	start, err := transformPosition(r.Start, through, startBias)
+	if err != nil {
+		return Range{}, err
+	}
+	end, err := transformPosition(r.End, through, endBias)
+	if err != nil {
+		return Range{}, err
+	}
+
+	return Range{Start: start, End: end}, nil
+}
+
+// Byte index in text at the position at, where text starts at the position start
+func indexAt(text string, start, at Position) (int, error) {
+	pos := start
+	for i, r := range text {
+		if pos == at {
+			return i, nil
+		}
+		if r == '\n' {
+			pos = Position{Line: pos.Line + 1, Character: 0}
+		} else {
+			pos.Character++
+		}
+	}
+	if pos == at {
+		return len(text), nil
+	}
+	return 0, fmt.Errorf("position %+v is not in the text from %+v", at, start)
+}
+
+// Text in the range r of the whole text
+func textInRange(text string, r Range) (string, error) {
+	i, err := indexAt(text, Position{}, r.Start)
+	if err != nil {
+		return "", err
+	}
+	j, err := indexAt(text, Position{}, r.End)
+	if err != nil {
+		return "", err
+	}
+	return text[i:j], nil
+}
+
+// Return the delete edit as a slice, dropping it if the range is empty
+func deleteEdits(text string, r Range) []Edit {
+	if r.Start == r.End {
+		return []Edit{}
+	}
+	return []Edit{EditDelete{DeleteText: text, DeleteRange: r}}
+}
+
+// Transform the delete through the insert, which may split the delete into two, to keep the inserted text
+func transformDeleteThroughInsert(e EditDelete, t EditInsert) ([]Edit, error) {
+	if t.NewText == "" || !lessThan(e.DeleteRange.Start, t.Position) || !lessThan(t.Position, e.DeleteRange.End) {
+		// the insert is outside the range, including the start and the end
+		r, err := transformRange(e.DeleteRange, t, BiasLeft)
+		if err != nil {
+			return nil, err
+		}
+		return deleteEdits(e.DeleteText, r), nil
+	}
+
+	insertEnd, err := editRangeEnd(t.Position, t.NewText)
+	if err != nil {
+		return nil, err
+	}
+	split, err := indexAt(e.DeleteText, e.DeleteRange.Start, t.Position)
+	if err != nil {
+		return nil, err
+	}
+
+	// Delete the latter part first, so that the former part's range is not affected
+	latter := Range{Start: insertEnd, End: shiftAfterInsert(e.DeleteRange.End, t.Position, insertEnd)}
+	former := Range{Start: e.DeleteRange.Start, End: t.Position}
+	return append(deleteEdits(e.DeleteText[split:], latter), deleteEdits(e.DeleteText[:split], former)...), nil
+}
+
+// Transform the delete through another delete, where the overlapped text is already deleted
+func transformDeleteThroughDelete(e EditDelete, t EditDelete) ([]Edit, error) {
+	text := e.DeleteText
+
+	overlapStart, overlapEnd := t.DeleteRange.Start, t.DeleteRange.End
+	if lessThan(overlapStart, e.DeleteRange.Start) {
+		overlapStart = e.DeleteRange.Start
+	}
+	if lessThan(e.DeleteRange.End, overlapEnd) {
+		overlapEnd = e.DeleteRange.End
+	}
+	if lessThan(overlapStart, overlapEnd) {
+		i, err := indexAt(text, e.DeleteRange.Start, overlapStart)
+		if err != nil {
+			return nil, err
+		}
+		j, err := indexAt(text, e.DeleteRange.Start, overlapEnd)
+		if err != nil {
+			return nil, err
+		}
+		text = text[:i] + text[j:]
+	}
+
+	r, err := transformRange(e.DeleteRange, t, BiasLeft)
+	if err != nil {
+		return nil, err
+	}
+	return deleteEdits(text, r), nil
+}
+
+// Transform the single edit through the single edit, which may result in zero, one or two edits
+func transformEdit(e Edit, through Edit, bias Bias) ([]Edit, error) {
+	switch through.(type) {
+	case EditInsert, EditDelete:
+	default:
+		return nil, fmt.Errorf("cannot transform through edit type %T", through)
+	}
+
+	switch e := e.(type) {
+	case EditInsert:
+		if e.NewText == "" {
+			return []Edit{}, nil
+		}
+		if !utf8.ValidString(e.NewText) {
+			return nil, fmt.Errorf("invalid UTF-8 text = '%s'", e.NewText)
+		}
+		p, err := transformPosition(e.Position, through, bias)
+		if err != nil {
+			return nil, err
+		}
+		return []Edit{EditInsert{NewText: e.NewText, Position: p}}, nil
+
+	case EditDelete:
+		if err := e.DeleteRange.Validate(); err != nil {
+			return nil, err
+		}
+		switch t := through.(type) {
+		case EditInsert:
+			return transformDeleteThroughInsert(e, t)
+		case EditDelete:
+			return transformDeleteThroughDelete(e, t)
+		}
+	}
+
+	return nil, fmt.Errorf("cannot transform edit type %T", e)
+}
+
+// Transform two concurrent sequences of edits against each other.
+// Return a', which applies after b, and b', which applies after a.
+func transformSequences(a, b []Edit, bias Bias) ([]Edit, []Edit, error) {
+	switch {
+	case len(a) == 0 || len(b) == 0:
+		return a, b, nil
+
+	case len(a) == 1 && len(b) == 1:
+		aPrime, err := transformEdit(a[0], b[0], bias)
+		if err != nil {
+			return nil, nil, err
+		}
+		bPrime, err := transformEdit(b[0], a[0], bias.opposite())
+		if err != nil {
+			return nil, nil, err
+		}
+		return aPrime, bPrime, nil
+
+	case len(a) > 1:
+		// a[0] goes through b, then the rest of a goes through b after a[0]
+		headPrime, b1, err := transformSequences(a[:1], b, bias)
+		if err != nil {
+			return nil, nil, err
+		}
+		restPrime, b2, err := transformSequences(a[1:], b1, bias)
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(headPrime, restPrime...), b2, nil
+
+	default:
+		// len(b) > 1, symmetric to the above
+		a1, headPrime, err := transformSequences(a, b[:1], bias)
+		if err != nil {
+			return nil, nil, err
+		}
+		a2, restPrime, err := transformSequences(a1, b[1:], bias)
+		if err != nil {
+			return nil, nil, err
+		}
+		return a2, append(headPrime, restPrime...), nil
+	}
+}
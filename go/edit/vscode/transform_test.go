@@ -0,0 +1,196 @@
+package vscode_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func TestTransformPosition(t *testing.T) {
+	insert := vscode.EditInsert{NewText: "xy\nz", Position: vscode.Position{Line: 1, Character: 2}}
+	delete := vscode.EditDelete{DeleteText: "c\nab", DeleteRange: vscode.Range{Start: vscode.Position{Line: 1, Character: 2}, End: vscode.Position{Line: 2, Character: 2}}}
+
+	cases := map[string]struct {
+		pos      vscode.Position
+		bias     vscode.Bias
+		through  vscode.Edit
+		expected vscode.Position
+	}{
+		"before insert":                 {vscode.Position{Line: 1, Character: 1}, vscode.BiasRight, insert, vscode.Position{Line: 1, Character: 1}},
+		"at insert, bias left":          {vscode.Position{Line: 1, Character: 2}, vscode.BiasLeft, insert, vscode.Position{Line: 1, Character: 2}},
+		"at insert, bias right":         {vscode.Position{Line: 1, Character: 2}, vscode.BiasRight, insert, vscode.Position{Line: 2, Character: 1}},
+		"after insert on the same line": {vscode.Position{Line: 1, Character: 5}, vscode.BiasLeft, insert, vscode.Position{Line: 2, Character: 4}},
+		"after insert on the next line": {vscode.Position{Line: 2, Character: 5}, vscode.BiasLeft, insert, vscode.Position{Line: 3, Character: 5}},
+		"before delete":                 {vscode.Position{Line: 1, Character: 2}, vscode.BiasRight, delete, vscode.Position{Line: 1, Character: 2}},
+		"inside delete":                 {vscode.Position{Line: 2, Character: 0}, vscode.BiasRight, delete, vscode.Position{Line: 1, Character: 2}},
+		"at delete end":                 {vscode.Position{Line: 2, Character: 2}, vscode.BiasLeft, delete, vscode.Position{Line: 1, Character: 2}},
+		"after delete on the end line":  {vscode.Position{Line: 2, Character: 5}, vscode.BiasLeft, delete, vscode.Position{Line: 1, Character: 5}},
+		"after delete on the next line": {vscode.Position{Line: 4, Character: 5}, vscode.BiasLeft, delete, vscode.Position{Line: 3, Character: 5}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := vscode.TransformPosition(c.pos, c.bias, c.through)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if d := cmp.Diff(c.expected, result); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+}
+
+func TestTransformRange(t *testing.T) {
+	r := vscode.Range{Start: vscode.Position{Line: 0, Character: 2}, End: vscode.Position{Line: 0, Character: 4}}
+	empty := vscode.Range{Start: vscode.Position{Line: 0, Character: 2}, End: vscode.Position{Line: 0, Character: 2}}
+
+	cases := map[string]struct {
+		r        vscode.Range
+		bias     vscode.Bias
+		through  []vscode.Edit
+		expected vscode.Range
+	}{
+		"insert at start": {r, vscode.BiasLeft, []vscode.Edit{vscode.EditInsert{NewText: "xx", Position: vscode.Position{Line: 0, Character: 2}}},
+			vscode.Range{Start: vscode.Position{Line: 0, Character: 4}, End: vscode.Position{Line: 0, Character: 6}}},
+		"insert at end": {r, vscode.BiasRight, []vscode.Edit{vscode.EditInsert{NewText: "xx", Position: vscode.Position{Line: 0, Character: 4}}},
+			r},
+		"empty, bias right": {empty, vscode.BiasRight, []vscode.Edit{vscode.EditInsert{NewText: "xx", Position: vscode.Position{Line: 0, Character: 2}}},
+			vscode.Range{Start: vscode.Position{Line: 0, Character: 4}, End: vscode.Position{Line: 0, Character: 4}}},
+		"sequence": {r, vscode.BiasLeft, []vscode.Edit{
+			vscode.EditInsert{NewText: "\n", Position: vscode.Position{Line: 0, Character: 0}},
+			vscode.EditDelete{DeleteText: "abc", DeleteRange: vscode.Range{Start: vscode.Position{Line: 1, Character: 1}, End: vscode.Position{Line: 1, Character: 4}}},
+		}, vscode.Range{Start: vscode.Position{Line: 1, Character: 1}, End: vscode.Position{Line: 1, Character: 1}}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := vscode.TransformRange(c.r, c.bias, c.through...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if d := cmp.Diff(c.expected, result); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+}
+
+func TestTransformEditsErrors(t *testing.T) {
+	move := vscode.EditMove{MoveText: "a\n", FromRange: vscode.Range{End: vscode.Position{Line: 1}}, ToPosition: vscode.Position{Line: 1}}
+	insert := vscode.EditInsert{NewText: "x", Position: vscode.Position{Line: 0, Character: 0}}
+
+	if result, err := vscode.TransformEdits([]vscode.Edit{insert}, vscode.BiasLeft, move); err == nil {
+		t.Errorf("Expected error: but succeeded with result = %+v", result)
+	}
+	if result, err := vscode.TransformEdits([]vscode.Edit{move}, vscode.BiasLeft, insert); err == nil {
+		t.Errorf("Expected error: but succeeded with result = %+v", result)
+	}
+}
+
+// Position of the rune offset in text
+func positionAt(text []rune, offset int) vscode.Position {
+	pos := vscode.Position{}
+	for _, r := range text[:offset] {
+		if r == '\n' {
+			pos = vscode.Position{Line: pos.Line + 1, Character: 0}
+		} else {
+			pos.Character++
+		}
+	}
+	return pos
+}
+
+func randomText(r *rand.Rand, n int) string {
+	alphabet := []rune("ab\n日")
+	runes := make([]rune, n)
+	for i := range runes {
+		runes[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(runes)
+}
+
+// Random sequence of edits, each of which is valid after the previous ones
+func randomEdits(t *testing.T, r *rand.Rand, text string, count int) []vscode.Edit {
+	var edits []vscode.Edit
+	for i := 0; i < count; i++ {
+		runes := []rune(text)
+		var edit vscode.Edit
+		if len(runes) == 0 || r.Intn(2) == 0 {
+			offset := r.Intn(len(runes) + 1)
+			edit = vscode.EditInsert{NewText: randomText(r, 1+r.Intn(4)), Position: positionAt(runes, offset)}
+		} else {
+			start := r.Intn(len(runes))
+			end := start + 1 + r.Intn(min(5, len(runes)-start))
+			edit = vscode.EditDelete{
+				DeleteText:  string(runes[start:end]),
+				DeleteRange: vscode.Range{Start: positionAt(runes, start), End: positionAt(runes, end)},
+			}
+		}
+		edits = append(edits, edit)
+		text = applyAll(t, text, edit)
+	}
+	return edits
+}
+
+func applyAll(t *testing.T, text string, edits ...vscode.Edit) string {
+	for i, e := range edits {
+		var err error
+		if text, err = e.Apply(text); err != nil {
+			t.Fatalf("failed to apply edit[%d] = %+v to '%s', %s", i, e, text, err)
+		}
+	}
+	return text
+}
+
+// apply(a then transform(b, a)) == apply(b then transform(a, b)), for random concurrent sequences a and b
+func TestTransformConvergence(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		doc := randomText(r, r.Intn(12))
+		a := randomEdits(t, r, doc, 1+r.Intn(3))
+		b := randomEdits(t, r, doc, 1+r.Intn(3))
+
+		bPrime, err := vscode.TransformEdits(b, vscode.BiasRight, a...)
+		if err != nil {
+			t.Fatalf("doc = %q, a = %+v, b = %+v, %s", doc, a, b, err)
+		}
+		aPrime, err := vscode.TransformEdits(a, vscode.BiasLeft, b...)
+		if err != nil {
+			t.Fatalf("doc = %q, a = %+v, b = %+v, %s", doc, a, b, err)
+		}
+
+		ab := applyAll(t, applyAll(t, doc, a...), bPrime...)
+		ba := applyAll(t, applyAll(t, doc, b...), aPrime...)
+		if ab != ba {
+			t.Fatalf("doc = %q, a = %+v, b = %+v, a' = %+v, b' = %+v, %s", doc, a, b, aPrime, bPrime, cmp.Diff(ab, ba))
+		}
+	}
+}
+
+// Transforming a position through edits keeps the rune next to it, unless the rune is deleted
+func TestTransformPositionKeepsRune(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 2000; i++ {
+		// Each rune in doc is unique, so that it can be found after edits
+		doc := []rune("0123456789")
+		offset := r.Intn(len(doc))
+		edits := randomEdits(t, r, string(doc), 1+r.Intn(3))
+
+		pos, err := vscode.TransformPosition(positionAt(doc, offset), vscode.BiasRight, edits...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		after := []rune(applyAll(t, string(doc), edits...))
+		for k, c := range after {
+			if c == doc[offset] && positionAt(after, k) != pos {
+				t.Fatalf("doc = %q, edits = %+v, expected %+v, but got %+v", string(doc), edits, positionAt(after, k), pos)
+			}
+		}
+	}
+}
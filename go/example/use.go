@@ -1,11 +1,13 @@
 package example
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/richardimaoka/typing-animation/go/player"
+	"github.com/richardimaoka/typing-animation/go/sink"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
@@ -106,8 +108,16 @@ func ExperimentFiles() {
 		panic(err)
 	}
 
-	for _, e := range edits {
-		e.ApplyToFile(resultFile)
-		time.Sleep(300 * time.Millisecond)
+	p := player.New(string(before), edits, sink.File(resultFile), player.Options{})
+	go func() {
+		for e := range p.Events() {
+			if e.Err == nil {
+				fmt.Printf("step %d/%d\n", e.Step, e.Total)
+			}
+		}
+	}()
+
+	if err := p.Play(context.Background()); err != nil {
+		panic(err)
 	}
 }
@@ -1,7 +1,41 @@
 package gitpkg
 
-import "github.com/richardimaoka/typing-animation/go/edit/vscode"
+import (
+	"fmt"
 
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Edits turning the file in beforeCommit into the file in afterCommit.
+// The file missing in either commit, i.e. added or deleted between them, is treated as empty.
+// The files have no size limits, same as EditsBetweenCommitsWithLimits with Limits{}.
 func EditsBetweenCommits(orgname, reponame, filePath, beforeCommit, afterCommit string) ([]vscode.Edit, error) {
-	return nil, nil
+	return EditsBetweenCommitsWithLimits(orgname, reponame, filePath, beforeCommit, afterCommit, Limits{})
+}
+
+// Same as EditsBetweenCommits, but the files in both commits are checked with limits, same as FileContentsInCommitWithLimits
+func EditsBetweenCommitsWithLimits(orgname, reponame, filePath, beforeCommit, afterCommit string, limits Limits) ([]vscode.Edit, error) {
+	errorPrefix := "gitpkg.EditsBetweenCommits failed"
+
+	repo, err := Open(orgname, reponame)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := fileContentsOrEmptyInternal(repo, beforeCommit, filePath, limits)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+	after, err := fileContentsOrEmptyInternal(repo, afterCommit, filePath, limits)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %w", errorPrefix, err)
+	}
+
+	edits, err := diff.CalcEdits(before, after)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	return edits, nil
 }
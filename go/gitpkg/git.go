@@ -9,6 +9,9 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
+// Error wrapped when the file doesn't exist in the commit, e.g. the commit deleting the file, to check with errors.Is
+var ErrFileNotFound = object.ErrFileNotFound
+
 func Open(orgname, reponame string) (*git.Repository, error) {
 	errorPrefix := "gitpkg.Open failed"
 
@@ -96,18 +99,24 @@ func FileInCommit(orgname, reponame, filePath, hashString string) (*object.File,
 
 	file, err := fileInCommitInternal(repo, hashString, filePath)
 	if err != nil {
-		return nil, fmt.Errorf("%s, %s", errorPrefix, err)
+		return nil, fmt.Errorf("%s, %w", errorPrefix, err)
 	}
 
 	return file, err
 }
 
+// File contents in the commit without size limits, same as FileContentsInCommitWithLimits with Limits{}
 func FileContentsInCommit(repo *git.Repository, hashString, filePath string) (string, error) {
+	return FileContentsInCommitWithLimits(repo, hashString, filePath, Limits{})
+}
+
+// Same as FileContentsInCommit, but returns *ErrBinaryFile, *ErrFileTooLarge or *ErrNotUTF8 wrapped, unless the file passes CheckContents with limits
+func FileContentsInCommitWithLimits(repo *git.Repository, hashString, filePath string, limits Limits) (string, error) {
 	errorPrefix := "gitpkg.FileContentsInCommit failed"
 
-	contents, err := fileContentsInCommitInternal(repo, hashString, filePath)
+	contents, err := fileContentsInCommitInternal(repo, hashString, filePath, limits)
 	if err != nil {
-		return "", fmt.Errorf("%s, %s", errorPrefix, err)
+		return "", fmt.Errorf("%s, %w", errorPrefix, err)
 	}
 
 	return contents, err
@@ -161,7 +170,13 @@ func RepoBranches(orgname, reponame string) ([]string, error) {
 	return branches, nil
 }
 
+// File contents in the commit without size limits, same as RepoFileContentsWithLimits with Limits{}
 func RepoFileContents(orgname, reponame, filePath, commitHashStr string) (string, error) {
+	return RepoFileContentsWithLimits(orgname, reponame, filePath, commitHashStr, Limits{})
+}
+
+// Same as RepoFileContents, but checks the file with limits, same as FileContentsInCommitWithLimits
+func RepoFileContentsWithLimits(orgname, reponame, filePath, commitHashStr string, limits Limits) (string, error) {
 	repo, err := OpenOrClone(orgname, reponame)
 	if err != nil {
 		return "", err
@@ -185,12 +200,12 @@ func RepoFileContents(orgname, reponame, filePath, commitHashStr string) (string
 
 	file, err := commit.File(filePath)
 	if err == object.ErrFileNotFound {
-		return "", fmt.Errorf("file = '%s' not found, %s", filePath, err)
+		return "", fmt.Errorf("file = '%s' not found, %w", filePath, err)
 	} else if err != nil {
 		return "", err
 	}
 
-	contents, err := file.Contents()
+	contents, err := readFileContents(file, limits)
 	if err != nil {
 		return "", err
 	}
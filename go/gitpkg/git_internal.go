@@ -1,6 +1,7 @@
 package gitpkg
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/go-git/go-git/v5"
@@ -110,22 +111,32 @@ func fileInCommitInternal(repo *git.Repository, hashString, filePath string) (*o
 
 	file, err := commit.File(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("error in file = '%s', %s", filePath, err)
+		return nil, fmt.Errorf("error in file = '%s', %w", filePath, err)
 	}
 
 	return file, err
 }
 
-func fileContentsInCommitInternal(repo *git.Repository, hashString, filePath string) (string, error) {
+func fileContentsInCommitInternal(repo *git.Repository, hashString, filePath string, limits Limits) (string, error) {
 	file, err := fileInCommitInternal(repo, hashString, filePath)
 	if err != nil {
 		return "", err
 	}
 
-	contents, err := file.Contents()
+	contents, err := readFileContents(file, limits)
 	if err != nil {
 		return "", err
 	}
 
 	return contents, err
 }
+
+// Same as fileContentsInCommitInternal, but empty contents if the file doesn't exist in the commit
+func fileContentsOrEmptyInternal(repo *git.Repository, hashString, filePath string, limits Limits) (string, error) {
+	contents, err := fileContentsInCommitInternal(repo, hashString, filePath, limits)
+	if errors.Is(err, ErrFileNotFound) {
+		return "", nil
+	}
+
+	return contents, err
+}
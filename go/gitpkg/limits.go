@@ -0,0 +1,77 @@
+package gitpkg
+
+import (
+	"fmt"
+)
+
+// Limits on files to animate, as larger files make the diff and the animation too slow
+type Limits struct {
+	// Maximum size of the file in bytes, or zero for no limit
+	MaxBytes int64
+	// Maximum number of lines in the file, or zero for no limit
+	MaxLines int
+}
+
+// Limits used by server.FileLimits, while the functions without WithLimits have no limits, i.e. Limits{}
+func DefaultLimits() Limits {
+	return Limits{MaxBytes: 1 << 20, MaxLines: 20000}
+}
+
+// Error when the file is binary, which cannot be animated as text
+type ErrBinaryFile struct {
+	FilePath string
+}
+
+// Error when the file exceeds the limits
+type ErrFileTooLarge struct {
+	FilePath string
+	Size     int64 // in bytes
+	Lines    int   // zero if the size already exceeds the limit, as the file is not read then
+	Limits   Limits
+}
+
+// Error when the file is text, but not encoded in UTF-8
+type ErrNotUTF8 struct {
+	FilePath string
+	// Best guess of the encoding, e.g. "UTF-16LE", "Shift_JIS", or "ISO-8859-1" as the last resort
+	Encoding string
+}
+
+func (e *ErrBinaryFile) Error() string {
+	return fmt.Sprintf("file = '%s' is binary", e.FilePath)
+}
+
+func (e *ErrFileTooLarge) Error() string {
+	if e.Limits.MaxBytes > 0 && e.Size > e.Limits.MaxBytes {
+		return fmt.Sprintf("file = '%s' has %d bytes, exceeding the limit of %d bytes", e.FilePath, e.Size, e.Limits.MaxBytes)
+	}
+	return fmt.Sprintf("file = '%s' has %d lines, exceeding the limit of %d lines", e.FilePath, e.Lines, e.Limits.MaxLines)
+}
+
+func (e *ErrNotUTF8) Error() string {
+	return fmt.Sprintf("file = '%s' is not UTF-8, but encoded in %s", e.FilePath, e.Encoding)
+}
+
+// Check the contents of the file can be animated, i.e. not binary, within the limits, and valid UTF-8.
+// Returns *ErrBinaryFile, *ErrFileTooLarge or *ErrNotUTF8 otherwise.
+func CheckContents(filePath string, contents []byte, limits Limits) error {
+	size := int64(len(contents))
+	if limits.MaxBytes > 0 && size > limits.MaxBytes {
+		return &ErrFileTooLarge{FilePath: filePath, Size: size, Limits: limits}
+	}
+
+	// Check encoding before binary, as UTF-16 and UTF-32 text has NUL bytes
+	encoding, isText := detectEncoding(contents)
+	if !isText {
+		return &ErrBinaryFile{FilePath: filePath}
+	}
+	if encoding != "UTF-8" {
+		return &ErrNotUTF8{FilePath: filePath, Encoding: encoding}
+	}
+
+	if lines := countLines(contents); limits.MaxLines > 0 && lines > limits.MaxLines {
+		return &ErrFileTooLarge{FilePath: filePath, Size: size, Lines: lines, Limits: limits}
+	}
+
+	return nil
+}
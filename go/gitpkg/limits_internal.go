@@ -0,0 +1,160 @@
+package gitpkg
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Number of bytes to look for NUL, same as git's binary detection
+const binaryCheckBytes = 8000
+
+// Ratio of control bytes in the first binaryCheckBytes, above which non-UTF-8 contents are binary
+const binaryControlRatio = 0.1
+
+// Read the file contents, checking the size before reading the whole blob
+func readFileContents(file *object.File, limits Limits) (string, error) {
+	if limits.MaxBytes > 0 && file.Size > limits.MaxBytes {
+		return "", &ErrFileTooLarge{FilePath: file.Name, Size: file.Size, Limits: limits}
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	if err := CheckContents(file.Name, contents, limits); err != nil {
+		return "", err
+	}
+
+	return string(contents), nil
+}
+
+func countLines(contents []byte) int {
+	if len(contents) == 0 {
+		return 0
+	}
+	lines := bytes.Count(contents, []byte("\n"))
+	if contents[len(contents)-1] != '\n' {
+		lines++ // the last line without '\n'
+	}
+	return lines
+}
+
+// Return the encoding of the contents, and false if the contents are binary
+func detectEncoding(contents []byte) (string, bool) {
+	// Byte order marks, where UTF-32LE must be checked before UTF-16LE as it starts with the same bytes
+	switch {
+	case bytes.HasPrefix(contents, []byte{0xEF, 0xBB, 0xBF}):
+		return detectEncoding(contents[3:])
+	case bytes.HasPrefix(contents, []byte{0xFF, 0xFE, 0x00, 0x00}):
+		return "UTF-32LE", true
+	case bytes.HasPrefix(contents, []byte{0x00, 0x00, 0xFE, 0xFF}):
+		return "UTF-32BE", true
+	case bytes.HasPrefix(contents, []byte{0xFF, 0xFE}):
+		return "UTF-16LE", true
+	case bytes.HasPrefix(contents, []byte{0xFE, 0xFF}):
+		return "UTF-16BE", true
+	}
+
+	head := contents[:min(len(contents), binaryCheckBytes)]
+	if bytes.IndexByte(head, 0) != -1 {
+		return "", false
+	}
+
+	if utf8.Valid(contents) {
+		return "UTF-8", true
+	}
+
+	// Binary without NUL would pass as ISO-8859-1 below, as any byte sequence is valid ISO-8859-1
+	if controlBytes(head) > int(float64(len(head))*binaryControlRatio) {
+		return "", false
+	}
+
+	switch {
+	// EUC-JP first, since EUC-JP text is often valid Shift_JIS too, but not vice versa
+	case isEUCJP(contents):
+		return "EUC-JP", true
+	case isShiftJIS(contents):
+		return "Shift_JIS", true
+	default:
+		// Any byte sequence is valid ISO-8859-1
+		return "ISO-8859-1", true
+	}
+}
+
+// Number of control bytes, except whitespace and ESC which text in terminal colors or ISO-2022-JP has
+func controlBytes(contents []byte) int {
+	count := 0
+	for _, b := range contents {
+		switch {
+		case b == '\t' || b == '\n' || b == '\v' || b == '\f' || b == '\r' || b == 0x1B:
+			continue
+		case b < 0x20 || b == 0x7F:
+			count++
+		}
+	}
+	return count
+}
+
+func isEUCJP(contents []byte) bool {
+	for i := 0; i < len(contents); i++ {
+		b := contents[i]
+		switch {
+		case b < 0x80:
+			continue
+		case b == 0x8E: // half-width katakana
+			if i+1 >= len(contents) || !inRange(contents[i+1], 0xA1, 0xDF) {
+				return false
+			}
+			i++
+		case b == 0x8F: // JIS X 0212
+			if i+2 >= len(contents) || !inRange(contents[i+1], 0xA1, 0xFE) || !inRange(contents[i+2], 0xA1, 0xFE) {
+				return false
+			}
+			i += 2
+		case inRange(b, 0xA1, 0xFE): // JIS X 0208
+			if i+1 >= len(contents) || !inRange(contents[i+1], 0xA1, 0xFE) {
+				return false
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isShiftJIS(contents []byte) bool {
+	for i := 0; i < len(contents); i++ {
+		b := contents[i]
+		switch {
+		case b < 0x80 || inRange(b, 0xA1, 0xDF): // ASCII, or half-width katakana
+			continue
+		case inRange(b, 0x81, 0x9F) || inRange(b, 0xE0, 0xFC):
+			if i+1 >= len(contents) {
+				return false
+			}
+			trail := contents[i+1]
+			if !inRange(trail, 0x40, 0x7E) && !inRange(trail, 0x80, 0xFC) {
+				return false
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func inRange(b, low, high byte) bool {
+	return low <= b && b <= high
+}
@@ -0,0 +1,65 @@
+package gitpkg_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/gitpkg"
+)
+
+func TestCheckContents(t *testing.T) {
+	limits := gitpkg.Limits{MaxBytes: 100, MaxLines: 3}
+
+	cases := map[string]struct {
+		contents []byte
+		limits   gitpkg.Limits
+		expected error
+	}{
+		"text":                        {[]byte("package main\n"), limits, nil},
+		"text with utf-8 bom":         {[]byte("\xEF\xBB\xBFこんにちは\n"), limits, nil},
+		"empty":                       {[]byte{}, limits, nil},
+		"lines within limit":          {[]byte("a\nb\nc"), limits, nil},
+		"no limits":                   {[]byte(strings.Repeat("a\n", 1000)), gitpkg.Limits{}, nil},
+		"ERROR: binary":               {[]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), limits, &gitpkg.ErrBinaryFile{FilePath: "file"}},
+		"ERROR: binary without NUL":   {[]byte("\x89\x01\x02\x03\xE9\x04\x05\x06\x07\x08\x0E\x0F"), limits, &gitpkg.ErrBinaryFile{FilePath: "file"}},
+		"ERROR: too many bytes":       {[]byte(strings.Repeat("a", 101)), limits, &gitpkg.ErrFileTooLarge{FilePath: "file", Size: 101, Limits: limits}},
+		"ERROR: too many lines":       {[]byte("a\nb\nc\nd\n"), limits, &gitpkg.ErrFileTooLarge{FilePath: "file", Size: 8, Lines: 4, Limits: limits}},
+		"ERROR: utf-16":               {[]byte("\xFF\xFEa\x00\n\x00"), limits, &gitpkg.ErrNotUTF8{FilePath: "file", Encoding: "UTF-16LE"}},
+		"ERROR: shift_jis":            {[]byte("\x82\xB1\x82\xF1\x82\xC9\x82\xBF\x82\xCD\n"), limits, &gitpkg.ErrNotUTF8{FilePath: "file", Encoding: "Shift_JIS"}},
+		"ERROR: euc-jp":               {[]byte("\xA4\xB3\xA4\xF3\xA4\xCB\xA4\xC1\xA4\xCF\n"), limits, &gitpkg.ErrNotUTF8{FilePath: "file", Encoding: "EUC-JP"}},
+		"ERROR: latin-1":              {[]byte("caf\xE9\n"), limits, &gitpkg.ErrNotUTF8{FilePath: "file", Encoding: "ISO-8859-1"}},
+		"ERROR: latin-1 with escapes": {[]byte("\x1B[31mcaf\xE9\x1B[0m\r\n"), limits, &gitpkg.ErrNotUTF8{FilePath: "file", Encoding: "ISO-8859-1"}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := gitpkg.CheckContents("file", c.contents, c.limits)
+			if err == nil {
+				if c.expected != nil {
+					t.Fatalf("Expected error: but succeeded for contents = %q", c.contents)
+				}
+				return
+			}
+			if c.expected == nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if d := cmp.Diff(c.expected, err); d != "" {
+				t.Errorf("%s", d)
+			}
+		})
+	}
+}
+
+func TestCheckContentsErrorsAs(t *testing.T) {
+	// Errors are typed, so that the server can tell the reason with errors.As even after wrapping
+	err := gitpkg.CheckContents("image.png", []byte("\x00\x01"), gitpkg.DefaultLimits())
+	err = fmt.Errorf("gitpkg.FileContentsInCommit failed, %w", err)
+
+	var binary *gitpkg.ErrBinaryFile
+	if !errors.As(err, &binary) || binary.FilePath != "image.png" {
+		t.Errorf("expected *gitpkg.ErrBinaryFile, but got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package tokens
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rune of identifiers and numbers, i.e. letters, digits and '_'
+func IsWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Split text into words, runs of whitespace (except '\n'), and single other chars including '\n'.
+// Shared by token-level diffs in the diff package, and splitting edits by token in the vscode package.
+//
+//	"foo(bar,  baz)\n" -> "foo", "(", "bar", ",", "  ", "baz", ")", "\n"
+func Tokenize(text string) []string {
+	var tokens []string
+
+	start := 0
+	for start < len(text) {
+		r, size := utf8.DecodeRuneInString(text[start:])
+		end := start + size
+
+		switch {
+		case IsWordRune(r):
+			for end < len(text) {
+				next, nextSize := utf8.DecodeRuneInString(text[end:])
+				if !IsWordRune(next) {
+					break
+				}
+				end += nextSize
+			}
+		case r != '\n' && unicode.IsSpace(r):
+			for end < len(text) {
+				next, nextSize := utf8.DecodeRuneInString(text[end:])
+				if next == '\n' || !unicode.IsSpace(next) {
+					break
+				}
+				end += nextSize
+			}
+		}
+
+		tokens = append(tokens, text[start:end])
+		start = end
+	}
+
+	return tokens
+}
@@ -0,0 +1,31 @@
+package tokens_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/internal/tokens"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := map[string]struct {
+		text     string
+		expected []string
+	}{
+		"empty":            {"", nil},
+		"words and spaces": {"foo bar  baz", []string{"foo", " ", "bar", "  ", "baz"}},
+		"punctuation":      {"foo(bar,baz)", []string{"foo", "(", "bar", ",", "baz", ")"}},
+		"new line":         {"\tfoo\n\n", []string{"\t", "foo", "\n", "\n"}},
+		"identifier":       {"new_context2 := x", []string{"new_context2", " ", ":", "=", " ", "x"}},
+		"Japanese":         {"英語とJapanese 混ぜて", []string{"英語とJapanese", " ", "混ぜて"}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result := tokens.Tokenize(c.text)
+			if diff := cmp.Diff(c.expected, result); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
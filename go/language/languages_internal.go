@@ -0,0 +1,201 @@
+package language
+
+// Languages shipped with Monaco, i.e. monaco-editor's basic-languages, plus css, html, json and typescript,
+// keyed by the language id with the lowercase extensions including '.'
+var languageExtensions = map[string][]string{
+	"abap":             {".abap"},
+	"aes":              {".aes"},
+	"apex":             {".cls"},
+	"azcli":            {".azcli"},
+	"bat":              {".bat", ".cmd"},
+	"bicep":            {".bicep"},
+	"c":                {".c", ".h"},
+	"cameligo":         {".mligo"},
+	"clojure":          {".clj", ".cljs", ".cljc", ".edn"},
+	"coffeescript":     {".coffee"},
+	"cpp":              {".cpp", ".cc", ".cxx", ".c++", ".hpp", ".hh", ".hxx", ".h++", ".ino"},
+	"csharp":           {".cs", ".csx", ".cake"},
+	"csp":              {},
+	"css":              {".css"},
+	"cypher":           {".cypher", ".cyp"},
+	"dart":             {".dart"},
+	"dockerfile":       {".dockerfile"},
+	"ecl":              {".ecl"},
+	"elixir":           {".ex", ".exs"},
+	"flow9":            {".flow"},
+	"freemarker2":      {".ftl", ".ftlh", ".ftlx"},
+	"fsharp":           {".fs", ".fsi", ".ml", ".mli", ".fsx", ".fsscript"},
+	"go":               {".go"},
+	"graphql":          {".graphql", ".gql"},
+	"handlebars":       {".handlebars", ".hbs"},
+	"hcl":              {".tf", ".tfvars", ".hcl"},
+	"html":             {".html", ".htm", ".shtml", ".xhtml", ".mdoc", ".jsp", ".asp", ".aspx", ".jshtm"},
+	"ini":              {".ini", ".properties", ".cfg", ".conf"},
+	"java":             {".java", ".jav"},
+	"javascript":       {".js", ".es6", ".jsx", ".mjs", ".cjs"},
+	"json":             {".json", ".jsonc", ".har", ".webmanifest"},
+	"julia":            {".jl"},
+	"kotlin":           {".kt", ".kts"},
+	"less":             {".less"},
+	"lexon":            {".lex"},
+	"liquid":           {".liquid", ".html.liquid"},
+	"lua":              {".lua"},
+	"m3":               {".m3", ".i3", ".mg", ".ig"},
+	"markdown":         {".md", ".markdown", ".mdown", ".mkdn", ".mkd", ".mdwn", ".mdtxt", ".mdtext"},
+	"mdx":              {".mdx"},
+	"mips":             {".s"},
+	"msdax":            {".dax", ".msdax"},
+	"mysql":            {},
+	"objective-c":      {".m"},
+	"pascal":           {".pas", ".p", ".pp"},
+	"pascaligo":        {".ligo"},
+	"perl":             {".pl", ".pm"},
+	"pgsql":            {},
+	"php":              {".php", ".php4", ".php5", ".phtml", ".ctp"},
+	"pla":              {".pla"},
+	"plaintext":        {".txt", ".text"},
+	"postiats":         {".dats", ".sats", ".hats"},
+	"powerquery":       {".pq", ".pqm"},
+	"powershell":       {".ps1", ".psm1", ".psd1"},
+	"proto":            {".proto"},
+	"pug":              {".jade", ".pug"},
+	"python":           {".py", ".rpy", ".pyw", ".cpy", ".gyp", ".gypi", ".pyi"},
+	"qsharp":           {".qs"},
+	"r":                {".r", ".rhistory", ".rmd", ".rprofile", ".rt"},
+	"razor":            {".cshtml"},
+	"redis":            {".redis"},
+	"redshift":         {},
+	"restructuredtext": {".rst"},
+	"ruby":             {".rb", ".rbx", ".rjs", ".gemspec", ".rake"},
+	"rust":             {".rs", ".rlib"},
+	"sb":               {".sb"},
+	"scala":            {".scala", ".sc", ".sbt"},
+	"scheme":           {".scm", ".ss", ".sch", ".rkt"},
+	"scss":             {".scss"},
+	"shell":            {".sh", ".bash", ".zsh", ".ksh"},
+	"sol":              {".sol"},
+	"sparql":           {".rq"},
+	"sql":              {".sql"},
+	"st":               {".st", ".iecst", ".iecplc", ".lc3lib", ".tcpou", ".tcdut", ".tcgvl", ".tcio"},
+	"swift":            {".swift"},
+	"systemverilog":    {".sv", ".svh"},
+	"tcl":              {".tcl"},
+	"twig":             {".twig"},
+	"typescript":       {".ts", ".tsx", ".cts", ".mts"},
+	"typespec":         {".tsp"},
+	"vb":               {".vb"},
+	"verilog":          {".v", ".vh"},
+	"wgsl":             {".wgsl"},
+	"xml":              {".xml", ".xsd", ".dtd", ".ascx", ".csproj", ".config", ".props", ".targets", ".wxi", ".wxl", ".wxs", ".xaml", ".svg", ".svgz", ".opf", ".xslt", ".xsl", ".plist"},
+	"yaml":             {".yaml", ".yml"},
+}
+
+// Files detected by the whole name, rather than the extension
+var fileNames = map[string]string{
+	"Dockerfile":        "dockerfile",
+	"Containerfile":     "dockerfile",
+	"Gemfile":           "ruby",
+	"Rakefile":          "ruby",
+	"Podfile":           "ruby",
+	"Vagrantfile":       "ruby",
+	"CMakeLists.txt":    "plaintext",
+	"go.mod":            "plaintext",
+	"go.sum":            "plaintext",
+	".bashrc":           "shell",
+	".bash_profile":     "shell",
+	".zshrc":            "shell",
+	".profile":          "shell",
+	".gitconfig":        "ini",
+	".editorconfig":     "ini",
+	".npmrc":            "ini",
+	".babelrc":          "json",
+	".eslintrc":         "json",
+	".prettierrc":       "json",
+	".jshintrc":         "json",
+	"tsconfig.json":     "json",
+	"package-lock.json": "json",
+}
+
+// Interpreters on the shebang line, without the version, e.g. "python" for "python3.12"
+var interpreters = map[string]string{
+	"sh":         "shell",
+	"bash":       "shell",
+	"zsh":        "shell",
+	"dash":       "shell",
+	"ksh":        "shell",
+	"python":     "python",
+	"node":       "javascript",
+	"nodejs":     "javascript",
+	"deno":       "typescript",
+	"bun":        "typescript",
+	"ts-node":    "typescript",
+	"tsx":        "typescript",
+	"ruby":       "ruby",
+	"perl":       "perl",
+	"php":        "php",
+	"Rscript":    "r",
+	"lua":        "lua",
+	"julia":      "julia",
+	"pwsh":       "powershell",
+	"powershell": "powershell",
+	"tclsh":      "tcl",
+	"wish":       "tcl",
+	"elixir":     "elixir",
+	"swift":      "swift",
+	"scala":      "scala",
+	"kotlin":     "kotlin",
+	"dart":       "dart",
+	"guile":      "scheme",
+	"racket":     "scheme",
+}
+
+// Vim filetypes and Emacs major modes which differ from the Monaco language id
+var modelineNames = map[string]string{
+	"sh":              "shell",
+	"bash":            "shell",
+	"zsh":             "shell",
+	"shell-script":    "shell",
+	"js":              "javascript",
+	"javascriptreact": "javascript",
+	"ts":              "typescript",
+	"typescriptreact": "typescript",
+	"py":              "python",
+	"rb":              "ruby",
+	"cs":              "csharp",
+	"c++":             "cpp",
+	"objc":            "objective-c",
+	"md":              "markdown",
+	"gfm":             "markdown",
+	"yml":             "yaml",
+	"ps1":             "powershell",
+	"dosbatch":        "bat",
+	"dosini":          "ini",
+	"conf":            "ini",
+	"rst":             "restructuredtext",
+	"rs":              "rust",
+	"tex":             "plaintext",
+	"text":            "plaintext",
+	"txt":             "plaintext",
+	"fundamental":     "plaintext",
+	"terraform":       "hcl",
+	"protobuf":        "proto",
+	"docker":          "dockerfile",
+	"racket":          "scheme",
+	"solidity":        "sol",
+}
+
+// Derived from languageExtensions
+var knownLanguages, extensions = indexLanguages(languageExtensions)
+
+// Return the set of language ids, and the map from an extension to the language id
+func indexLanguages(languageExtensions map[string][]string) (map[string]struct{}, map[string]string) {
+	known := map[string]struct{}{}
+	exts := map[string]string{}
+	for id, languageExts := range languageExtensions {
+		known[id] = struct{}{}
+		for _, ext := range languageExts {
+			exts[ext] = id
+		}
+	}
+	return known, exts
+}
@@ -0,0 +1,45 @@
+package language
+
+import (
+	"sort"
+)
+
+// Monaco language id for files of an unknown language
+const PlainText = "plaintext"
+
+// Detect the Monaco language id of the file, or PlainText if unknown.
+//
+// The language is detected in the order of:
+//   - Vim or Emacs modeline in the first or last lines, e.g. "vim: set ft=python:" or "-*- mode: ruby -*-"
+//   - File name, e.g. Dockerfile, or extension, e.g. ".go"
+//   - Shebang on the first line, e.g. "#!/usr/bin/env python3"
+//
+// so that the author's explicit modeline wins over the file name, and the shebang covers scripts without an extension.
+func Detect(filePath, contents string) string {
+	if lang, ok := fromModeline(contents); ok {
+		return lang
+	}
+	if lang, ok := fromFileName(filePath); ok {
+		return lang
+	}
+	if lang, ok := fromShebang(contents); ok {
+		return lang
+	}
+	return PlainText
+}
+
+// True if id is a Monaco language id, e.g. to validate a language overridden by the client
+func IsKnown(id string) bool {
+	_, ok := knownLanguages[id]
+	return ok
+}
+
+// Monaco language ids which Detect can return, sorted
+func Languages() []string {
+	languages := make([]string, 0, len(knownLanguages))
+	for id := range knownLanguages {
+		languages = append(languages, id)
+	}
+	sort.Strings(languages)
+	return languages
+}
@@ -0,0 +1,109 @@
+package language
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Number of lines to look for a modeline at the beginning and the end of the file, same as Vim's default 'modelines'
+const modelineLines = 5
+
+// Language of the file by its name or extension
+func fromFileName(filePath string) (string, bool) {
+	base := path.Base(filePath)
+	if lang, ok := fileNames[base]; ok {
+		return lang, true
+	}
+
+	// The first '.' gives the longest extension, so ".html.liquid" is checked before ".liquid".
+	// A leading '.' is not an extension but a dotfile, e.g. ".bashrc", which is in fileNames.
+	name := strings.ToLower(base)
+	for i := 1; i < len(name); i++ {
+		if name[i] != '.' {
+			continue
+		}
+		if lang, ok := extensions[name[i:]]; ok {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// Language of the script by the interpreter on the shebang line, e.g. "#!/bin/bash" or "#!/usr/bin/env -S python3 -u"
+func fromShebang(contents string) (string, bool) {
+	firstLine, _, _ := strings.Cut(contents, "\n")
+	shebang, ok := strings.CutPrefix(firstLine, "#!")
+	if !ok {
+		return "", false
+	}
+
+	fields := strings.Fields(shebang)
+	if len(fields) == 0 {
+		return "", false
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// skip env's options, e.g. -S, to find the interpreter
+		interpreter = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interpreter = path.Base(f)
+				break
+			}
+		}
+	}
+
+	// Strip the version, e.g. python3.12 to python
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	lang, ok := interpreters[interpreter]
+	return lang, ok
+}
+
+var (
+	// e.g. "vim: set ft=python:", "vi: filetype=sh", or "ex: syntax=ruby"
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vim?|ex):.*?\b(?:ft|filetype|syntax)=([\w+#-]+)`)
+	// e.g. "-*- mode: python -*-", or "-*- python -*-"
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?\bmode:\s*([\w+#-]+)|([\w+#-]+))\s*(?:;.*?)?-\*-`)
+)
+
+// Language of the file by Vim or Emacs modeline in the first or last lines
+func fromModeline(contents string) (string, bool) {
+	lines := strings.Split(contents, "\n")
+	candidates := lines
+	if len(lines) > 2*modelineLines {
+		candidates = append(lines[:modelineLines:modelineLines], lines[len(lines)-modelineLines:]...)
+	}
+
+	for _, line := range candidates {
+		var name string
+		if m := vimModeline.FindStringSubmatch(line); m != nil {
+			name = m[1]
+		} else if m := emacsModeline.FindStringSubmatch(line); m != nil {
+			name = m[1] + m[2] // either of them is matched
+		} else {
+			continue
+		}
+
+		if lang, ok := fromModelineName(name); ok {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// Monaco language id for a Vim filetype or Emacs major mode, e.g. "sh" and "shell-script" to "shell"
+func fromModelineName(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if _, ok := knownLanguages[name]; ok {
+		return name, true
+	}
+	if lang, ok := modelineNames[name]; ok {
+		return lang, true
+	}
+	// Emacs major modes may have a suffix, e.g. "python-ts" or "js-jsx"
+	if base, _, found := strings.Cut(name, "-"); found {
+		return fromModelineName(base)
+	}
+	return "", false
+}
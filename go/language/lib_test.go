@@ -0,0 +1,69 @@
+package language_test
+
+import (
+	"testing"
+
+	"github.com/richardimaoka/typing-animation/go/language"
+)
+
+func TestDetect(t *testing.T) {
+	cases := map[string]struct {
+		filePath string
+		contents string
+		expected string
+	}{
+		"extension":                   {"go/main.go", "package main\n", "go"},
+		"upper-case extension":        {"README.MD", "# title\n", "markdown"},
+		"multiple dots":               {"templates/page.html.liquid", "{{ title }}\n", "liquid"},
+		"file name":                   {"build/Dockerfile", "FROM golang\n", "dockerfile"},
+		"dotfile":                     {"home/.bashrc", "alias ll='ls -l'\n", "shell"},
+		"shebang":                     {"bin/run", "#!/bin/bash\necho hi\n", "shell"},
+		"shebang with env":            {"scripts/build", "#!/usr/bin/env python3.12\nprint('hi')\n", "python"},
+		"shebang with env options":    {"scripts/serve", "#!/usr/bin/env -S deno run --allow-net\n", "typescript"},
+		"extension over shebang":      {"tool.rb", "#!/bin/sh\nexec ruby -x \"$0\"\n", "ruby"},
+		"vim modeline":                {"config/app", "[section]\n# vim: set ts=2 ft=dosini:\n", "ini"},
+		"vim modeline over extension": {"script.txt", "echo hi\n# vi: filetype=sh\n", "shell"},
+		"emacs modeline":              {"bin/tool", "#!/bin/sh\n# -*- mode: ruby; coding: utf-8 -*-\n", "ruby"},
+		"emacs short modeline":        {"lib/thing", ";; -*- python -*-\n", "python"},
+		"emacs mode with suffix":      {"lib/thing", "# -*- mode: python-ts -*-\n", "python"},
+		"emacs coding only":           {"lib/thing.js", "// -*- coding: utf-8 -*-\n", "javascript"},
+		"unknown modeline":            {"main.go", "// vim: ft=nosuchlanguage\n", "go"},
+		"unknown":                     {"data/blob.xyz", "\x00\x01", "plaintext"},
+		"no extension":                {"LICENSE", "MIT License\n", "plaintext"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result := language.Detect(c.filePath, c.contents)
+			if c.expected != result {
+				t.Errorf("expected %s, but got %s", c.expected, result)
+			}
+		})
+	}
+}
+
+func TestDetectModelineInTheMiddle(t *testing.T) {
+	// modelines are only in the first or last lines, same as Vim
+	contents := "a\nb\nc\nd\ne\n# vim: ft=python\nf\ng\nh\ni\nj\nk\n"
+	if result := language.Detect("notes", contents); result != language.PlainText {
+		t.Errorf("expected %s, but got %s", language.PlainText, result)
+	}
+}
+
+func TestIsKnown(t *testing.T) {
+	for _, id := range language.Languages() {
+		if !language.IsKnown(id) {
+			t.Errorf("expected %s to be known", id)
+		}
+	}
+	for _, id := range []string{"go", "typescript", "shell", "objective-c", language.PlainText} {
+		if !language.IsKnown(id) {
+			t.Errorf("expected %s to be known", id)
+		}
+	}
+	for _, id := range []string{"", "golang", "sh", "Go"} {
+		if language.IsKnown(id) {
+			t.Errorf("expected %s to be unknown", id)
+		}
+	}
+}
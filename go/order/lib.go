@@ -0,0 +1,41 @@
+package order
+
+import (
+	"fmt"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Heuristics to reorder edits into a natural editing order, rather than top to bottom
+type Options struct {
+	// Keep edits within the same top-level Go declaration together, so that a declaration is written in one go
+	GroupByDeclaration bool
+	// Move edits defining a top-level identifier before edits using it, e.g. a function definition before its call site
+	DefinitionsFirst bool
+	// Move edits in import declarations to the end, as imports are usually added after the code needing them
+	ImportsLast bool
+}
+
+// Options with all the heuristics enabled
+func DefaultOptions() Options {
+	return Options{
+		GroupByDeclaration: true,
+		DefinitionsFirst:   true,
+		ImportsLast:        true,
+	}
+}
+
+// Reorder edits into a natural editing order, rebasing their positions so that they still turn before into the same text.
+//
+// edits must be in document order against before, like the ones from vscode.EditStack.CalcEdits.
+// Edits other than EditInsert and EditDelete (e.g. EditMove) are kept in place, and only the edits between them are reordered.
+func Reorder(before string, edits []vscode.Edit, opts Options) ([]vscode.Edit, error) {
+	errorPrefix := "order.Reorder failed"
+
+	result, err := reorderInternal(before, edits, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	return result, nil
+}
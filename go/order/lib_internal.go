@@ -0,0 +1,422 @@
+package order
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+var (
+	// Line starting a top-level Go declaration
+	declRegexp = regexp.MustCompile(`(?m)^(func|type|var|const|import)\b`)
+	// Identifiers defined by top-level Go declarations, including methods
+	defineRegexp = regexp.MustCompile(`(?m)^(?:func\s+(?:\([^)]*\)\s*)?|type\s+|var\s+|const\s+)([A-Za-z_]\w*)`)
+	identRegexp  = regexp.MustCompile(`[A-Za-z_]\w*`)
+)
+
+// Single insert or delete, as a change to the text before the whole run of edits.
+// Changes never overlap, and seq is the order in the document, so any subset of changes can be applied in any order,
+// by shifting the offset by the changes with smaller seq applied so far.
+type change struct {
+	seq      int
+	offset   int // rune offset in the original text
+	deleted  []rune
+	inserted []rune
+}
+
+func (c change) delta() int {
+	return len(c.inserted) - len(c.deleted)
+}
+
+func (c change) end() int {
+	return c.offset + len(c.deleted)
+}
+
+// Group of changes reordered as a whole
+type unit struct {
+	changes []change
+	decl    int    // index of the top-level declaration which the unit belongs to, -1 if none
+	keyword string // keyword of the declaration, e.g. "func" and "import"
+	newDecl bool   // whether the unit inserts a new top-level declaration
+	defines []string
+	uses    map[string]bool
+}
+
+// Convert the position to the rune offset in text
+func toOffset(text []rune, pos vscode.Position) (int, error) {
+	line, offset := 0, 0
+	for line < pos.Line {
+		if offset == len(text) {
+			return 0, fmt.Errorf("line = %d is out of range in position %+v", pos.Line, pos)
+		}
+		if text[offset] == '\n' {
+			line++
+		}
+		offset++
+	}
+
+	for c := 0; c < pos.Character; c++ {
+		if offset == len(text) || text[offset] == '\n' {
+			return 0, fmt.Errorf("character = %d is out of range in position %+v", pos.Character, pos)
+		}
+		offset++
+	}
+
+	return offset, nil
+}
+
+// Convert the rune offset in text to the position
+func toPosition(text []rune, offset int) vscode.Position {
+	pos := vscode.Position{}
+	for _, r := range text[:offset] {
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+		} else {
+			pos.Character++
+		}
+	}
+	return pos
+}
+
+// Replace text[start:end] by replacement
+func splice(text []rune, start, end int, replacement []rune) []rune {
+	result := make([]rune, 0, len(text)-(end-start)+len(replacement))
+	result = append(result, text[:start]...)
+	result = append(result, replacement...)
+	return append(result, text[end:]...)
+}
+
+// Convert edits in document order into changes to before, where first is the index of edits[0] for error messages
+func toChanges(before []rune, edits []vscode.Edit, first int) ([]change, error) {
+	current := before
+	delta, lastEnd := 0, 0
+
+	var changes []change
+	for k, e := range edits {
+		i := first + k
+		var start, end int
+		var inserted []rune
+
+		switch e := e.(type) {
+		case vscode.EditInsert:
+			offset, err := toOffset(current, e.Position)
+			if err != nil {
+				return nil, fmt.Errorf("edit[%d] %s", i, err)
+			}
+			start, end, inserted = offset, offset, []rune(e.NewText)
+		case vscode.EditDelete:
+			var err error
+			if start, err = toOffset(current, e.DeleteRange.Start); err != nil {
+				return nil, fmt.Errorf("edit[%d] %s", i, err)
+			}
+			if end, err = toOffset(current, e.DeleteRange.End); err != nil {
+				return nil, fmt.Errorf("edit[%d] %s", i, err)
+			}
+		default:
+			return nil, fmt.Errorf("edit[%d] has unsupported type %T", i, e)
+		}
+
+		c := change{
+			seq:      len(changes),
+			offset:   start - delta,
+			deleted:  append([]rune{}, current[start:end]...),
+			inserted: inserted,
+		}
+		if c.offset < lastEnd {
+			return nil, fmt.Errorf("edit[%d] = %+v is not in document order", i, e)
+		}
+
+		changes = append(changes, c)
+		current = splice(current, start, end, inserted)
+		delta += c.delta()
+		lastEnd = c.end()
+	}
+
+	return changes, nil
+}
+
+// Offsets of the top-level declarations in text, and their keywords
+func declarations(text []rune) ([]int, []string) {
+	var offsets []int
+	var keywords []string
+
+	str := string(text)
+	for _, loc := range declRegexp.FindAllStringSubmatchIndex(str, -1) {
+		// byte offset to rune offset
+		offsets = append(offsets, len([]rune(str[:loc[0]])))
+		keywords = append(keywords, str[loc[2]:loc[3]])
+	}
+
+	return offsets, keywords
+}
+
+// Split inserts containing top-level declarations, so that each new declaration becomes a separate change.
+//
+// The text before the first new declaration is often the end of the enclosing one, as in the insert
+// "\tcall()\n}\n\nfunc f() {\n" before "}\n", which is slid into "\tcall()\n" before "}\n" and "\nfunc f() {\n}\n" after it,
+// unless the slide crosses the next change.
+func splitDeclarations(before []rune, changes []change) []change {
+	var result []change
+	for i, c := range changes {
+		if len(c.deleted) > 0 || !declRegexp.MatchString(string(c.inserted)) {
+			result = append(result, c)
+			continue
+		}
+
+		limit := len(before)
+		if i+1 < len(changes) {
+			limit = changes[i+1].offset
+		}
+		result = append(result, splitInsert(before[:limit], c)...)
+	}
+
+	for i := range result {
+		result[i].seq = i
+	}
+	return result
+}
+
+func splitInsert(before []rune, c change) []change {
+	var pieces []change
+	text, offset := c.inserted, c.offset
+
+	starts, _ := declarations(text)
+	if first := starts[0]; first > 0 {
+		head := text[:first]
+
+		// Find the whole lines head[p:p+length] matching the original text after the insert, to slide them
+		p, length := first, 0
+		for lineStart := 0; lineStart < first; lineStart++ {
+			if lineStart > 0 && head[lineStart-1] != '\n' {
+				continue
+			}
+			matched, wholeLines := 0, 0
+			for lineStart+matched < first && offset+matched < len(before) && head[lineStart+matched] == before[offset+matched] {
+				matched++
+				if head[lineStart+matched-1] == '\n' {
+					wholeLines = matched
+				}
+			}
+			if wholeLines > length {
+				p, length = lineStart, wholeLines
+			}
+		}
+
+		// head[:p] is inserted at offset, and the rest followed by the matched lines is inserted after the matched lines
+		if p > 0 {
+			pieces = append(pieces, change{offset: offset, inserted: head[:p]})
+		}
+		var rest []rune
+		rest = append(rest, head[p+length:]...)
+		rest = append(rest, text[first:]...)
+		rest = append(rest, head[p:p+length]...)
+		text, offset = rest, offset+length
+		starts, _ = declarations(text)
+	}
+
+	// Split the rest at each declaration except the first one
+	prev := 0
+	for _, start := range starts[1:] {
+		pieces = append(pieces, change{offset: offset, inserted: text[prev:start]})
+		prev = start
+	}
+	return append(pieces, change{offset: offset, inserted: text[prev:]})
+}
+
+// Group changes into units, where touching changes always belong to the same unit
+func groupUnits(before []rune, changes []change, opts Options) []unit {
+	declOffsets, declKeywords := declarations(before)
+
+	var units []unit
+	for _, c := range changes {
+		decl := -1
+		for decl+1 < len(declOffsets) && declOffsets[decl+1] <= c.offset {
+			decl++
+		}
+		keyword := ""
+		if decl >= 0 {
+			keyword = declKeywords[decl]
+		}
+
+		inserted := string(c.inserted)
+		newDecl := false
+		if m := declRegexp.FindStringSubmatch(inserted); m != nil {
+			newDecl = true
+			keyword = m[1]
+		}
+
+		if n := len(units); n > 0 {
+			last := &units[n-1]
+			touching := !newDecl && last.changes[len(last.changes)-1].end() == c.offset
+			sameDecl := opts.GroupByDeclaration && !newDecl && !last.newDecl && last.decl == decl
+			if touching || sameDecl {
+				last.changes = append(last.changes, c)
+				last.addIdentifiers(inserted)
+				continue
+			}
+		}
+
+		u := unit{changes: []change{c}, decl: decl, keyword: keyword, newDecl: newDecl, uses: map[string]bool{}}
+		u.addIdentifiers(inserted)
+		units = append(units, u)
+	}
+
+	return units
+}
+
+func (u *unit) addIdentifiers(inserted string) {
+	for _, m := range defineRegexp.FindAllStringSubmatch(inserted, -1) {
+		u.defines = append(u.defines, m[1])
+	}
+	for _, ident := range identRegexp.FindAllString(inserted, -1) {
+		u.uses[ident] = true
+	}
+}
+
+func (u unit) dependsOn(other unit) bool {
+	for _, ident := range other.defines {
+		if u.uses[ident] {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort units by the heuristics, keeping the document order as much as possible
+func sortUnits(units []unit, opts Options) []unit {
+	sorted := units
+
+	if opts.DefinitionsFirst {
+		// Topological sort, picking the first unit in the document order among the ones whose definitions are all done,
+		// and breaking a cycle by the first unit in the document order
+		done := make([]bool, len(units))
+		sorted = make([]unit, 0, len(units))
+		for len(sorted) < len(units) {
+			next := -1
+			for i := range units {
+				if done[i] {
+					continue
+				}
+				if next == -1 {
+					next = i // fallback for a cycle
+				}
+
+				ready := true
+				for j := range units {
+					if i != j && !done[j] && units[i].dependsOn(units[j]) {
+						ready = false
+						break
+					}
+				}
+				if ready {
+					next = i
+					break
+				}
+			}
+			done[next] = true
+			sorted = append(sorted, units[next])
+		}
+	}
+
+	if opts.ImportsLast {
+		var others, imports []unit
+		for _, u := range sorted {
+			if u.keyword == "import" {
+				imports = append(imports, u)
+			} else {
+				others = append(others, u)
+			}
+		}
+		sorted = append(others, imports...)
+	}
+
+	return sorted
+}
+
+// Apply the changes in the order of units, and calculate the edits with positions rebased on the text so far
+func rebase(before []rune, units []unit, changeCount int) ([]vscode.Edit, []rune) {
+	current := before
+	deltas := make([]int, changeCount) // deltas[seq] = delta of the change if applied, otherwise zero
+
+	var edits []vscode.Edit
+	for _, u := range units {
+		for _, c := range u.changes {
+			offset := c.offset
+			for seq := 0; seq < c.seq; seq++ {
+				offset += deltas[seq]
+			}
+
+			if len(c.deleted) > 0 {
+				deleteRange := vscode.Range{Start: toPosition(current, offset), End: toPosition(current, offset+len(c.deleted))}
+				edits = append(edits, vscode.EditDelete{DeleteText: string(c.deleted), DeleteRange: deleteRange})
+			}
+			if len(c.inserted) > 0 {
+				edits = append(edits, vscode.EditInsert{NewText: string(c.inserted), Position: toPosition(current, offset)})
+			}
+
+			current = splice(current, offset, offset+len(c.deleted), c.inserted)
+			deltas[c.seq] = c.delta()
+		}
+	}
+
+	return edits, current
+}
+
+// Reorder a run of EditInsert and EditDelete
+func reorderRun(before []rune, edits []vscode.Edit, first int, opts Options) ([]vscode.Edit, []rune, error) {
+	changes, err := toChanges(before, edits, first)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.GroupByDeclaration {
+		changes = splitDeclarations(before, changes)
+	}
+
+	units := sortUnits(groupUnits(before, changes, opts), opts)
+	reordered, after := rebase(before, units, len(changes))
+	return reordered, after, nil
+}
+
+func reorderInternal(before string, edits []vscode.Edit, opts Options) ([]vscode.Edit, error) {
+	result := []vscode.Edit{}
+	current := []rune(before)
+
+	start := 0 // start index of the current run of EditInsert and EditDelete
+	flush := func(end int) error {
+		reordered, after, err := reorderRun(current, edits[start:end], start, opts)
+		if err != nil {
+			return err
+		}
+		result = append(result, reordered...)
+		current = after
+		return nil
+	}
+
+	for i, e := range edits {
+		switch e.(type) {
+		case vscode.EditInsert, vscode.EditDelete:
+			continue
+		}
+
+		// Other edits are kept in place, splitting runs
+		if err := flush(i); err != nil {
+			return nil, err
+		}
+		after, err := e.Apply(string(current))
+		if err != nil {
+			return nil, fmt.Errorf("edit[%d] %s", i, err)
+		}
+		result = append(result, e)
+		current = []rune(after)
+		start = i + 1
+	}
+
+	if err := flush(len(edits)); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,180 @@
+package order_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/richardimaoka/typing-animation/go/order"
+)
+
+const mainBefore = `package main
+
+func main() {
+	println("hello")
+}
+`
+
+const mainAfter = `package main
+
+import "fmt"
+
+func main() {
+	println("hello")
+	fmt.Println(greet("world"))
+}
+
+func greet(name string) string {
+	return "hello " + name
+}
+`
+
+func applyEdits(t *testing.T, before string, edits []vscode.Edit) string {
+	result := before
+	for i, e := range edits {
+		var err error
+		result, err = e.Apply(result)
+		if err != nil {
+			t.Fatalf("failed to apply edit[%d] = %+v, %s", i, e, err)
+		}
+	}
+	return result
+}
+
+// Index of the first edit inserting text which contains substr
+func indexOfInsert(edits []vscode.Edit, substr string) int {
+	for i, e := range edits {
+		if insert, ok := e.(vscode.EditInsert); ok && strings.Contains(insert.NewText, substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestReorder(t *testing.T) {
+	inputs := map[string]struct {
+		before string
+		after  string
+	}{
+		"definition and import": {mainBefore, mainAfter},
+		"reverse":               {mainAfter, mainBefore},
+		"multi-byte":            {"// こんにちは\nfunc a() {}\n", "// こんばんは\nfunc b() {}\nfunc a() { b() }\n"},
+		"no newline at end":     {"func a() {}", "func b() {}\nfunc a() { b() }"},
+	}
+
+	diffOptions := map[string]diff.Options{
+		"char diff": diff.DefaultOptions(),
+		"line mode": {LineMode: true, Refine: diff.RefineWord},
+	}
+
+	orderOptions := map[string]order.Options{
+		"default": order.DefaultOptions(),
+		"none":    {},
+		"imports": {ImportsLast: true},
+	}
+
+	for inputName, input := range inputs {
+		for diffName, diffOpts := range diffOptions {
+			for orderName, orderOpts := range orderOptions {
+				t.Run(inputName+", "+diffName+", "+orderName, func(t *testing.T) {
+					edits, err := diff.CalcEditsWithOptions(input.before, input.after, diffOpts)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					reordered, err := order.Reorder(input.before, edits, orderOpts)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					result := applyEdits(t, input.before, reordered)
+					if input.after != result {
+						t.Errorf("%s", cmp.Diff(input.after, result))
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestReorderHeuristics(t *testing.T) {
+	edits, err := diff.CalcEditsWithOptions(mainBefore, mainAfter, diff.Options{LineMode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reordered, err := order.Reorder(mainBefore, edits, order.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	definition := indexOfInsert(reordered, "func greet")
+	callSite := indexOfInsert(reordered, "greet(\"world\")")
+	imports := indexOfInsert(reordered, "import")
+	if definition == -1 || callSite == -1 || imports == -1 {
+		t.Fatalf("missing edits in %+v", reordered)
+	}
+
+	if !(definition < callSite && callSite < imports) {
+		t.Errorf("expected definition < call site < import, but got %d, %d, %d in %+v", definition, callSite, imports, reordered)
+	}
+}
+
+func TestReorderKeepsOtherEdits(t *testing.T) {
+	opts := diff.Options{LineMode: true, Reindent: true}
+	before := "func a() {}\nx()\ny()\n"
+	after := "func b() {}\n\nfunc a() { b() }\nif ok {\n\tx()\n\ty()\n}\n"
+
+	edits, err := diff.CalcEditsWithOptions(before, after, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reordered, err := order.Reorder(before, edits, order.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := reordered[0].(vscode.EditReindent); !ok {
+		t.Errorf("expected EditReindent to be kept first, but got %+v", reordered)
+	}
+
+	result := applyEdits(t, before, reordered)
+	if after != result {
+		t.Errorf("%s", cmp.Diff(after, result))
+	}
+}
+
+func TestReorderErrors(t *testing.T) {
+	cases := map[string]struct {
+		before string
+		edits  []vscode.Edit
+	}{
+		"not in document order": {
+			"abc\n",
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "x", Position: vscode.Position{Line: 0, Character: 2}},
+				vscode.EditInsert{NewText: "y", Position: vscode.Position{Line: 0, Character: 0}},
+			},
+		},
+		"line out of range": {
+			"abc\n",
+			[]vscode.Edit{vscode.EditInsert{NewText: "x", Position: vscode.Position{Line: 3, Character: 0}}},
+		},
+		"character out of range": {
+			"abc\n",
+			[]vscode.Edit{vscode.EditDelete{DeleteText: "abcd", DeleteRange: vscode.Range{Start: vscode.Position{Line: 0, Character: 0}, End: vscode.Position{Line: 0, Character: 4}}}},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := order.Reorder(c.before, c.edits, order.DefaultOptions())
+			if err == nil {
+				t.Fatalf("Expected error: but succeeded with result = %+v", result)
+			}
+		})
+	}
+}
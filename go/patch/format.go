@@ -0,0 +1,63 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Same as the default of `diff -u` and `git diff`
+const DefaultContext = 3
+
+type FormatOptions struct {
+	OldName string // written as "--- a/OldName", or "--- /dev/null" if OldName is "/dev/null"
+	NewName string // written as "+++ b/NewName", or "+++ /dev/null" if NewName is "/dev/null"
+	Context int    // number of unchanged lines around each change
+}
+
+// Format the change made by stack as a unified diff.
+// If the stack has no change, an empty string is returned.
+func FormatStack(stack *vscode.EditStack, opts FormatOptions) (string, error) {
+	errorPrefix := "patch.FormatStack failed"
+
+	var before, after strings.Builder
+	for _, d := range stack.Diffs() {
+		switch d.Type {
+		case vscode.DiffEqual:
+			before.WriteString(d.Text)
+			after.WriteString(d.Text)
+		case vscode.DiffDelete:
+			before.WriteString(d.Text)
+		case vscode.DiffInsert:
+			after.WriteString(d.Text)
+		default:
+			return "", fmt.Errorf("%s, diff type = %d is invalid", errorPrefix, d.Type)
+		}
+	}
+
+	result, err := formatInternal(before.String(), after.String(), opts)
+	if err != nil {
+		return "", fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	return result, nil
+}
+
+// Format the change made by applying edits to before, as a unified diff.
+// If edits make no change, an empty string is returned.
+func FormatEdits(before string, edits []vscode.Edit, opts FormatOptions) (string, error) {
+	errorPrefix := "patch.FormatEdits failed"
+
+	after, err := vscode.ApplyEdits(before, edits)
+	if err != nil {
+		return "", fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	result, err := formatInternal(before, after, opts)
+	if err != nil {
+		return "", fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,177 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func (opts FormatOptions) validate() error {
+	errs := []string{}
+
+	if opts.OldName == "" {
+		errs = append(errs, "empty OldName")
+	}
+	if opts.NewName == "" {
+		errs = append(errs, "empty NewName")
+	}
+	if opts.Context < 0 {
+		errs = append(errs, fmt.Sprintf("negative Context = %d", opts.Context))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid format options, %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// Line-by-line diff between before and after.
+// Each Line.Text includes the trailing '\n', except the last line of a text not ending in '\n'.
+func diffLines(before, after string) []Line {
+	dmp := diffmatchpatch.New()
+	chars1, chars2, lineArray := dmp.DiffLinesToChars(before, after)
+	diffs := dmp.DiffMain(chars1, chars2, false)
+	diffs = dmp.DiffCharsToLines(diffs, lineArray)
+
+	var lines []Line
+	for _, d := range diffs {
+		var lineType vscode.DiffOperation
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			lineType = vscode.DiffDelete
+		case diffmatchpatch.DiffEqual:
+			lineType = vscode.DiffEqual
+		case diffmatchpatch.DiffInsert:
+			lineType = vscode.DiffInsert
+		default:
+			panic(fmt.Sprintf("unexpected diff match patch type = %d (%s)", d.Type, d.Type.String()))
+		}
+
+		for _, l := range strings.SplitAfter(d.Text, "\n") {
+			// if d.Text ends in '\n', the last line is ""
+			if l != "" {
+				lines = append(lines, Line{Type: lineType, Text: l})
+			}
+		}
+	}
+
+	return lines
+}
+
+// Group lines into hunks, each of which has at most `context` unchanged lines around changes.
+// Changes separated by at most 2 * context unchanged lines, i.e. whose context lines would touch or overlap,
+// are merged into the same hunk, same as `diff -u`.
+func groupHunks(lines []Line, context int) []Hunk {
+	// oldBefore[i] and newBefore[i] = the number of old and new lines before lines[i]
+	oldBefore := make([]int, len(lines)+1)
+	newBefore := make([]int, len(lines)+1)
+	for i, l := range lines {
+		oldBefore[i+1] = oldBefore[i]
+		newBefore[i+1] = newBefore[i]
+		if l.Type != vscode.DiffInsert {
+			oldBefore[i+1]++
+		}
+		if l.Type != vscode.DiffDelete {
+			newBefore[i+1]++
+		}
+	}
+
+	var hunks []Hunk
+	for i := 0; i < len(lines); i++ {
+		if lines[i].Type == vscode.DiffEqual {
+			continue
+		}
+
+		start := max(0, i-context)
+
+		lastChange := i
+		for j := i + 1; j < len(lines) && j-lastChange <= 2*context+1; j++ {
+			if lines[j].Type != vscode.DiffEqual {
+				lastChange = j
+			}
+		}
+		end := min(len(lines), lastChange+1+context)
+
+		hunk := Hunk{
+			OldStart: oldBefore[start] + 1,
+			OldLines: oldBefore[end] - oldBefore[start],
+			NewStart: newBefore[start] + 1,
+			NewLines: newBefore[end] - newBefore[start],
+			Lines:    lines[start:end],
+		}
+		// If the hunk has no line on the side, the start is the line *after which* the hunk applies
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		hunks = append(hunks, hunk)
+
+		i = end - 1 // i++ in the loop, so the next iteration starts from end
+	}
+
+	return hunks
+}
+
+// "start,count", or just "start" if count is 1, same as `git diff`
+func formatHunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func formatFileName(name, prefix string) string {
+	if name == devNull {
+		return name
+	}
+	return prefix + name
+}
+
+func writeHunk(builder *strings.Builder, hunk Hunk) {
+	fmt.Fprintf(builder, "@@ -%s +%s @@\n", formatHunkRange(hunk.OldStart, hunk.OldLines), formatHunkRange(hunk.NewStart, hunk.NewLines))
+
+	for _, l := range hunk.Lines {
+		switch l.Type {
+		case vscode.DiffEqual:
+			builder.WriteString(" ")
+		case vscode.DiffDelete:
+			builder.WriteString("-")
+		case vscode.DiffInsert:
+			builder.WriteString("+")
+		}
+		builder.WriteString(l.Text)
+
+		if !strings.HasSuffix(l.Text, "\n") {
+			builder.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func formatInternal(before, after string, opts FormatOptions) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+	if opts.OldName == devNull && before != "" {
+		return "", errors.New("OldName is /dev/null, but before is not empty")
+	}
+
+	hunks := groupHunks(diffLines(before, after), opts.Context)
+	if len(hunks) == 0 {
+		return "", nil
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %s\n", formatFileName(opts.OldName, "a/"))
+	fmt.Fprintf(&builder, "+++ %s\n", formatFileName(opts.NewName, "b/"))
+	for _, h := range hunks {
+		writeHunk(&builder, h)
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,196 @@
+package patch_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/richardimaoka/typing-animation/go/patch"
+)
+
+func TestFormatStack(t *testing.T) {
+	patchFile, err := os.Open("testdata/format_patch.patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer patchFile.Close()
+
+	files, err := patch.Parse(patchFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePatch, _ := patch.Find(files, "main.go")
+
+	before, err := os.ReadFile("testdata/main_before.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stack, err := filePatch.Stack(string(before))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := patch.FormatStack(stack, patch.FormatOptions{OldName: "main.go", NewName: "main.go", Context: patch.DefaultContext})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same as `git diff` output, except the hunk heading like "func sub(a, b int) int {"
+	golden, err := os.ReadFile("testdata/main_golden.patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := string(golden)
+	if expected != result {
+		t.Errorf("%s", cmp.Diff(expected, result))
+	}
+}
+
+func TestFormatEdits(t *testing.T) {
+	cases := map[string]struct {
+		before  string
+		edits   []vscode.Edit
+		context int
+		hunks   int
+	}{
+		"no change": {
+			"abc\n",
+			nil,
+			3,
+			0,
+		},
+		"insert in the middle": {
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			[]vscode.Edit{vscode.EditInsert{NewText: "inserted\n", Position: vscode.Position{Line: 5, Character: 0}}},
+			3,
+			1,
+		},
+		"zero context": {
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "x", Position: vscode.Position{Line: 1, Character: 1}},
+				vscode.EditInsert{NewText: "y", Position: vscode.Position{Line: 8, Character: 1}},
+			},
+			0,
+			2,
+		},
+		"gap of 2 * context, merged": {
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "x", Position: vscode.Position{Line: 1, Character: 1}},
+				vscode.EditInsert{NewText: "y", Position: vscode.Position{Line: 4, Character: 1}},
+			},
+			1,
+			1,
+		},
+		"gap of 2 * context + 1, separate": {
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			[]vscode.Edit{
+				vscode.EditInsert{NewText: "x", Position: vscode.Position{Line: 1, Character: 1}},
+				vscode.EditInsert{NewText: "y", Position: vscode.Position{Line: 5, Character: 1}},
+			},
+			1,
+			2,
+		},
+		"delete last newline": {
+			"1\n2\n3\n",
+			[]vscode.Edit{vscode.EditDelete{DeleteText: "\n", DeleteRange: vscode.Range{Start: vscode.Position{Line: 2, Character: 1}, End: vscode.Position{Line: 3, Character: 0}}}},
+			3,
+			1,
+		},
+		"add last newline": {
+			"1\n2\n3",
+			[]vscode.Edit{vscode.EditInsert{NewText: "\n", Position: vscode.Position{Line: 2, Character: 1}}},
+			1,
+			1,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// 1. Preparation
+			after := c.before
+			for _, e := range c.edits {
+				var err error
+				if after, err = e.Apply(after); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 2. Target operation
+			opts := patch.FormatOptions{OldName: "file.txt", NewName: "file.txt", Context: c.context}
+			result, err := patch.FormatEdits(c.before, c.edits, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 3. Check results
+			//    The formatted diff should be parsed back, and produce the same result
+			if len(c.edits) == 0 {
+				if result != "" {
+					t.Fatalf("expected empty diff, but got '%s'", result)
+				}
+				return
+			}
+
+			if hunks := strings.Count(result, "\n@@ "); hunks != c.hunks {
+				t.Errorf("expected %d hunks, but got %d\n%s", c.hunks, hunks, result)
+			}
+
+			files, err := patch.Parse(strings.NewReader(result))
+			if err != nil {
+				t.Fatal(err)
+			}
+			edits, err := files[0].CalcEdits(c.before)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roundTrip := c.before
+			for _, e := range edits {
+				if roundTrip, err = e.Apply(roundTrip); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if after != roundTrip {
+				t.Errorf("%s", cmp.Diff(after, roundTrip))
+			}
+
+			//    The formatted diff should be accepted by `git apply`
+			gitApply(t, c.before, after, result)
+		})
+	}
+}
+
+// Apply patchText to before with `git apply`, and compare the result to after
+func gitApply(t *testing.T, before, after, patchText string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Log("git not found, skipping git apply")
+		return
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(before), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file.patch"), []byte(patchText), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("git", "apply", "--unidiff-zero", "file.patch")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git apply failed, %s, %s\n%s", err, output, patchText)
+	}
+
+	result, err := os.ReadFile(filepath.Join(dir, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != string(result) {
+		t.Errorf("%s", cmp.Diff(after, string(result)))
+	}
+}
@@ -0,0 +1,115 @@
+package patch
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Patch for a single file, as in a `diff --git` section of a unified diff
+type FilePatch struct {
+	OldName string // "/dev/null" if the patch creates the file
+	NewName string // "/dev/null" if the patch deletes the file
+	Hunks   []Hunk
+}
+
+// Same as the `@@ -OldStart,OldLines +NewStart,NewLines @@` section of a unified diff
+type Hunk struct {
+	OldStart int // one-based, as written in the hunk header
+	OldLines int
+	NewStart int // one-based, as written in the hunk header
+	NewLines int
+	Lines    []Line
+}
+
+// Single line in a hunk.
+// Text includes the trailing '\n', unless the line is marked with `\ No newline at end of file`
+type Line struct {
+	Type vscode.DiffOperation
+	Text string
+}
+
+// Parse a unified diff, which can contain multiple files and multiple hunks per file.
+// Anything before the first file header (e.g. `git format-patch` email headers and commit message) is ignored.
+func Parse(reader io.Reader) ([]FilePatch, error) {
+	errorPrefix := "patch.Parse failed"
+
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	files, err := parseInternal(string(bytes))
+	if err != nil {
+		return nil, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	return files, nil
+}
+
+// Build EditStack which turns base into the patched contents.
+// base must be the contents of f.OldName, otherwise an error is returned
+func (f FilePatch) Stack(base string) (*vscode.EditStack, error) {
+	errorPrefix := fmt.Sprintf("patch.FilePatch.Stack failed for file = '%s'", f.NewName)
+
+	stack, err := stackInternal(f.Hunks, base)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %s", errorPrefix, err)
+	}
+
+	return stack, nil
+}
+
+// Calculate edits which turn base into the patched contents
+func (f FilePatch) CalcEdits(base string) ([]vscode.Edit, error) {
+	stack, err := f.Stack(base)
+	if err != nil {
+		return nil, err
+	}
+
+	return stack.CalcEdits()
+}
+
+// Find the patch for filePath, matching either of the old or new name
+func Find(files []FilePatch, filePath string) (FilePatch, bool) {
+	for _, f := range files {
+		if f.NewName == filePath || f.OldName == filePath {
+			return f, true
+		}
+	}
+	return FilePatch{}, false
+}
+
+// Whether the patch creates a new file
+func (f FilePatch) IsNew() bool {
+	return f.OldName == devNull
+}
+
+// Whether the patch deletes the file
+func (f FilePatch) IsDeleted() bool {
+	return f.NewName == devNull
+}
+
+// Text of the lines in the hunk, as the hunk appears before the patch
+func (h Hunk) OldText() string {
+	var builder strings.Builder
+	for _, l := range h.Lines {
+		if l.Type != vscode.DiffInsert {
+			builder.WriteString(l.Text)
+		}
+	}
+	return builder.String()
+}
+
+// Text of the lines in the hunk, as the hunk appears after the patch
+func (h Hunk) NewText() string {
+	var builder strings.Builder
+	for _, l := range h.Lines {
+		if l.Type != vscode.DiffDelete {
+			builder.WriteString(l.Text)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,289 @@
+package patch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+const devNull = "/dev/null"
+
+// Strip "a/" or "b/" prefix, and the timestamp which some diff tools put after a tab
+//
+//	"--- a/go/main.go"                        -> "go/main.go"
+//	"+++ b/go/main.go\t2024-01-01 00:00:00"   -> "go/main.go"
+func parseFileName(headerLine, marker string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(headerLine, marker), "\n")
+	name, _, _ = strings.Cut(name, "\t")
+	if name == devNull {
+		return name
+	}
+
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		return name[2:]
+	}
+	return name
+}
+
+// Parse names from "diff --git a/old b/new".
+// This is only a fallback, as the names are ambiguous if they contain " b/",
+// and overwritten by "---" and "+++" lines if they exist.
+func parseGitHeader(line string) (string, string) {
+	names := strings.TrimSuffix(strings.TrimPrefix(line, "diff --git "), "\n")
+	oldName, newName, found := strings.Cut(names, " b/")
+	if !found {
+		return "", ""
+	}
+	return strings.TrimPrefix(oldName, "a/"), newName
+}
+
+// Parse "start,count" or "start" in the hunk header, where count defaults to 1
+func parseHunkRange(s string) (int, int, error) {
+	startStr, countStr, hasCount := strings.Cut(s, ",")
+
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk range = '%s', %s", s, err)
+	}
+
+	if !hasCount {
+		return start, 1, nil
+	}
+
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk range = '%s', %s", s, err)
+	}
+
+	return start, count, nil
+}
+
+// Parse "@@ -OldStart,OldLines +NewStart,NewLines @@ optional section heading"
+func parseHunkHeader(line string) (Hunk, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[0] != "@@" || fields[3] != "@@" ||
+		!strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return Hunk{}, fmt.Errorf("invalid hunk header = '%s'", strings.TrimSuffix(line, "\n"))
+	}
+
+	oldStart, oldLines, err := parseHunkRange(fields[1][1:])
+	if err != nil {
+		return Hunk{}, err
+	}
+
+	newStart, newLines, err := parseHunkRange(fields[2][1:])
+	if err != nil {
+		return Hunk{}, err
+	}
+
+	return Hunk{OldStart: oldStart, OldLines: oldLines, NewStart: newStart, NewLines: newLines}, nil
+}
+
+// Parse hunk lines, starting from lines[0] which is the line right after the hunk header.
+// Return the hunk and the number of lines consumed.
+func parseHunkBody(hunk Hunk, lines []string) (Hunk, int, error) {
+	oldRemaining := hunk.OldLines
+	newRemaining := hunk.NewLines
+
+	i := 0
+	for ; i < len(lines); i++ {
+		line := lines[i]
+
+		// "\ No newline at end of file" applies to the previous line
+		if strings.HasPrefix(line, `\`) {
+			if len(hunk.Lines) == 0 {
+				return Hunk{}, 0, fmt.Errorf("'%s' appeared without a preceding line", strings.TrimSuffix(line, "\n"))
+			}
+			last := &hunk.Lines[len(hunk.Lines)-1]
+			last.Text = strings.TrimSuffix(last.Text, "\n")
+			continue
+		}
+
+		// All the lines in the hunk are consumed, but keep going only for the "\ No newline" line above
+		if oldRemaining == 0 && newRemaining == 0 {
+			break
+		}
+
+		if line == "" {
+			// reached the end of the patch
+			break
+		} else if line == "\n" {
+			// Some editors strip the trailing ' ' from an empty context line
+			line = " \n"
+		}
+
+		switch line[0] {
+		case ' ':
+			hunk.Lines = append(hunk.Lines, Line{Type: vscode.DiffEqual, Text: line[1:]})
+			oldRemaining--
+			newRemaining--
+		case '-':
+			hunk.Lines = append(hunk.Lines, Line{Type: vscode.DiffDelete, Text: line[1:]})
+			oldRemaining--
+		case '+':
+			hunk.Lines = append(hunk.Lines, Line{Type: vscode.DiffInsert, Text: line[1:]})
+			newRemaining--
+		default:
+			return Hunk{}, 0, fmt.Errorf("unexpected line = '%s' in hunk", strings.TrimSuffix(line, "\n"))
+		}
+
+		if oldRemaining < 0 || newRemaining < 0 {
+			return Hunk{}, 0, fmt.Errorf("hunk has more lines than its header @@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+		}
+	}
+
+	if oldRemaining != 0 || newRemaining != 0 {
+		return Hunk{}, 0, fmt.Errorf("unexpected end of hunk @@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+	}
+
+	return hunk, i, nil
+}
+
+func parseInternal(text string) ([]FilePatch, error) {
+	lines := strings.SplitAfter(text, "\n")
+
+	var files []FilePatch
+	var current *FilePatch
+	// Whether "---" and "+++" lines are already read for current
+	headerDone := false
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			oldName, newName := parseGitHeader(line)
+			files = append(files, FilePatch{OldName: oldName, NewName: newName})
+			current = &files[len(files)-1]
+			headerDone = false
+
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if current == nil || headerDone {
+				// plain unified diff without "diff --git" line
+				files = append(files, FilePatch{})
+				current = &files[len(files)-1]
+			}
+			current.OldName = parseFileName(line, "--- ")
+			current.NewName = parseFileName(lines[i+1], "+++ ")
+			headerDone = true
+			i++ // skip "+++" line
+
+		case strings.HasPrefix(line, "@@ "):
+			if current == nil {
+				return nil, fmt.Errorf("hunk at line = %d appeared before any file header", i+1)
+			}
+
+			hunk, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line = %d, %s", i+1, err)
+			}
+
+			hunk, consumed, err := parseHunkBody(hunk, lines[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("line = %d, %s", i+1, err)
+			}
+
+			current.Hunks = append(current.Hunks, hunk)
+			i += consumed
+
+		default:
+			// Ignore the rest, like "index ..." line, email headers and commit messages
+		}
+	}
+
+	return files, nil
+}
+
+// Append diffs to EditStack, merging consecutive diffs of the same type,
+// so that a run of deleted lines becomes a single delete edit.
+type stackBuilder struct {
+	stack       *vscode.EditStack
+	pendingType vscode.DiffOperation
+	pendingText strings.Builder
+}
+
+func (b *stackBuilder) append(diffType vscode.DiffOperation, text string) {
+	if text == "" {
+		return
+	}
+	if b.pendingText.Len() > 0 && b.pendingType != diffType {
+		b.flush()
+	}
+	b.pendingType = diffType
+	b.pendingText.WriteString(text)
+}
+
+func (b *stackBuilder) flush() {
+	if b.pendingText.Len() == 0 {
+		return
+	}
+
+	text := b.pendingText.String()
+	switch b.pendingType {
+	case vscode.DiffEqual:
+		b.stack.AppendEqual(text)
+	case vscode.DiffDelete:
+		b.stack.AppendDelete(text)
+	case vscode.DiffInsert:
+		b.stack.AppendInsert(text)
+	}
+	b.pendingText.Reset()
+}
+
+func stackInternal(hunks []Hunk, base string) (*vscode.EditStack, error) {
+	baseLines := strings.SplitAfter(base, "\n")
+	if baseLines[len(baseLines)-1] == "" {
+		// base ends in '\n', or base is empty
+		baseLines = baseLines[:len(baseLines)-1]
+	}
+
+	builder := stackBuilder{stack: vscode.NewEditStack()}
+
+	lineIndex := 0 // zero-based line index in base
+	for _, h := range hunks {
+		// If OldLines == 0, OldStart is the line *after which* the hunk is inserted
+		hunkStart := h.OldStart - 1
+		if h.OldLines == 0 {
+			hunkStart = h.OldStart
+		}
+
+		if hunkStart < lineIndex {
+			return nil, fmt.Errorf("hunk @@ -%d,%d @@ overlaps with the previous hunk", h.OldStart, h.OldLines)
+		}
+		if hunkStart > len(baseLines) {
+			return nil, fmt.Errorf("hunk @@ -%d,%d @@ starts after the end of base, which has %d lines", h.OldStart, h.OldLines, len(baseLines))
+		}
+
+		// lines between hunks
+		for ; lineIndex < hunkStart; lineIndex++ {
+			builder.append(vscode.DiffEqual, baseLines[lineIndex])
+		}
+
+		for _, l := range h.Lines {
+			if l.Type == vscode.DiffInsert {
+				builder.append(vscode.DiffInsert, l.Text)
+				continue
+			}
+
+			if lineIndex >= len(baseLines) {
+				return nil, fmt.Errorf("hunk @@ -%d,%d @@ expects line = %d, but base has only %d lines", h.OldStart, h.OldLines, lineIndex+1, len(baseLines))
+			}
+			if baseLines[lineIndex] != l.Text {
+				return nil, fmt.Errorf("hunk @@ -%d,%d @@ does not match base at line = %d, expected '%s', but found '%s'", h.OldStart, h.OldLines, lineIndex+1, l.Text, baseLines[lineIndex])
+			}
+
+			builder.append(l.Type, l.Text)
+			lineIndex++
+		}
+	}
+
+	// lines after the last hunk
+	for ; lineIndex < len(baseLines); lineIndex++ {
+		builder.append(vscode.DiffEqual, baseLines[lineIndex])
+	}
+	builder.flush()
+
+	return builder.stack, nil
+}
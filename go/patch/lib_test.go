@@ -0,0 +1,118 @@
+package patch_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/patch"
+)
+
+func TestParse(t *testing.T) {
+	file, err := os.Open("testdata/format_patch.patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	files, err := patch.Parse(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type summary struct {
+		OldName string
+		NewName string
+		Hunks   int
+	}
+	var result []summary
+	for _, f := range files {
+		result = append(result, summary{f.OldName, f.NewName, len(f.Hunks)})
+	}
+
+	expected := []summary{
+		{"main.go", "main.go", 2},
+		{"notes.txt", "notes.txt", 1},
+	}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+func TestCalcEdits(t *testing.T) {
+	cases := map[string]struct {
+		patchFile  string
+		filePath   string
+		beforeFile string
+		afterFile  string
+	}{
+		"multiple hunks":            {"testdata/format_patch.patch", "main.go", "testdata/main_before.txt", "testdata/main_after.txt"},
+		"no newline at end of file": {"testdata/format_patch.patch", "notes.txt", "testdata/notes_before.txt", "testdata/notes_after.txt"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// 1. Preparation
+			patchFile, err := os.Open(c.patchFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer patchFile.Close()
+
+			files, err := patch.Parse(patchFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filePatch, found := patch.Find(files, c.filePath)
+			if !found {
+				t.Fatalf("file = '%s' not found in the patch", c.filePath)
+			}
+
+			before, err := os.ReadFile(c.beforeFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after, err := os.ReadFile(c.afterFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 2. Target operation
+			edits, err := filePatch.CalcEdits(string(before))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 3. Check results
+			result := string(before)
+			for _, e := range edits {
+				result, err = e.Apply(result)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if string(after) != result {
+				t.Errorf("%s", cmp.Diff(string(after), result))
+			}
+		})
+	}
+}
+
+func TestStackMismatch(t *testing.T) {
+	text := `--- a/file.txt
++++ b/file.txt
+@@ -1,2 +1,2 @@
+ first line
+-second line
++second line changed
+`
+	files, err := patch.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := files[0].Stack("first line\nsomething else\n"); err == nil {
+		t.Fatal("expected error but succeeded")
+	}
+}
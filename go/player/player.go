@@ -0,0 +1,154 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/richardimaoka/typing-animation/go/sink"
+)
+
+// Default interval between steps, at speed = 1
+const DefaultInterval = 300 * time.Millisecond
+
+type Options struct {
+	// Interval between steps at speed = 1, DefaultInterval if zero
+	Interval time.Duration
+	// Speed multiplier, e.g. 2 plays twice as fast, 1 if zero
+	Speed float64
+	// Start paused, until Resume or Seek is called
+	Paused bool
+}
+
+// Progress of the playback, sent to Events() after each step.
+// Err is set when the step failed, and it is the last event.
+type Event struct {
+	Step  int // number of edits applied so far
+	Total int
+	Err   error
+}
+
+// Player drives a sequence of edits onto a sink, one step at a time.
+// Pause, Resume, Seek and SetSpeed can be called from any goroutine while Play is running.
+type Player struct {
+	before string
+	edits  []vscode.Edit
+	sink   sink.Sink
+	events chan Event
+	// Wakes up the playback loop when the state below is changed
+	wake chan struct{}
+
+	// Only touched by the playback loop, so outside mu
+	doc *vscode.Document
+
+	mu       sync.Mutex
+	step     int // updated after the sink succeeded
+	seekTo   int // -1 if no pending seek
+	paused   bool
+	interval time.Duration
+	speed    float64
+}
+
+// Use sink.Multi to drive multiple sinks, e.g. a file and a terminal
+func New(before string, edits []vscode.Edit, s sink.Sink, opts Options) *Player {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Speed <= 0 {
+		opts.Speed = 1
+	}
+
+	return &Player{
+		before:   before,
+		edits:    edits,
+		sink:     s,
+		events:   make(chan Event, 16),
+		wake:     make(chan struct{}, 1),
+		doc:      vscode.NewDocument(before),
+		seekTo:   -1,
+		paused:   opts.Paused,
+		interval: opts.Interval,
+		speed:    opts.Speed,
+	}
+}
+
+// Progress and errors of the playback, which must be drained while playing.
+// The channel is closed when Play returns.
+func (p *Player) Events() <-chan Event {
+	return p.events
+}
+
+// Play the edits until the last step, or until ctx is canceled.
+// The sink is reset to before first.
+// Play can be called only once.
+func (p *Player) Play(ctx context.Context) error {
+	errorPrefix := "player.Play failed"
+	defer close(p.events)
+
+	if err := p.sink.Reset(p.before); err != nil {
+		err = fmt.Errorf("%s, %w", errorPrefix, err)
+		p.send(ctx, Event{Step: 0, Total: len(p.edits), Err: err})
+		return err
+	}
+
+	if err := p.loop(ctx); err != nil {
+		if ctx.Err() == nil {
+			err = fmt.Errorf("%s, %w", errorPrefix, err)
+			p.send(ctx, Event{Step: p.Step(), Total: len(p.edits), Err: err})
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *Player) Pause() {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+	p.notify()
+}
+
+func (p *Player) Resume() {
+	p.mu.Lock()
+	p.paused = false
+	p.mu.Unlock()
+	p.notify()
+}
+
+// Jump to the state after step edits, i.e. 0 is before, len(edits) is after all the edits.
+// Playback continues from there unless paused.
+func (p *Player) Seek(step int) error {
+	if step < 0 || len(p.edits) < step {
+		return fmt.Errorf("player.Seek failed, step = %d is out of range [0, %d]", step, len(p.edits))
+	}
+
+	p.mu.Lock()
+	p.seekTo = step
+	p.mu.Unlock()
+	p.notify()
+
+	return nil
+}
+
+func (p *Player) SetSpeed(speed float64) error {
+	if speed <= 0 {
+		return fmt.Errorf("player.SetSpeed failed, speed = %f must be positive", speed)
+	}
+
+	p.mu.Lock()
+	p.speed = speed
+	p.mu.Unlock()
+	p.notify()
+
+	return nil
+}
+
+// Number of edits applied so far
+func (p *Player) Step() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.step
+}
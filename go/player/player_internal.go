@@ -0,0 +1,132 @@
+package player
+
+import (
+	"context"
+	"time"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+func (p *Player) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default: // already notified
+	}
+}
+
+func (p *Player) send(ctx context.Context, e Event) {
+	select {
+	case p.events <- e:
+	case <-ctx.Done():
+	}
+}
+
+func (p *Player) loop(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		seekTo, paused, step := p.seekTo, p.paused, p.step
+		wait := time.Duration(float64(p.interval) / p.speed)
+		p.mu.Unlock()
+
+		// 1. Seek first, even when paused, so that the sink shows the frame
+		if seekTo >= 0 {
+			step, err := p.seek()
+			if err != nil {
+				return err
+			}
+			p.send(ctx, Event{Step: step, Total: len(p.edits)})
+			continue
+		}
+
+		if step == len(p.edits) && !paused {
+			return nil
+		}
+
+		// 2. Wait for the next step, or for the state change
+		var tick <-chan time.Time
+		timer := time.NewTimer(wait)
+		if !paused {
+			tick = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-p.wake:
+			timer.Stop()
+			continue
+		case <-tick:
+		}
+
+		// 3. Apply the next step
+		if err := p.advance(); err != nil {
+			return err
+		}
+		p.send(ctx, Event{Step: step + 1, Total: len(p.edits)})
+	}
+}
+
+// Apply the next edit to the document and the sink, and advance the step only after both succeeded,
+// so that the step isn't counted as done when the sink failed.
+// The sink is called outside the lock, as it can be slow, e.g. a file or a websocket, and must not block Pause or Seek.
+func (p *Player) advance() error {
+	p.mu.Lock()
+	step := p.step
+	p.mu.Unlock()
+
+	edit := p.edits[step]
+	if err := p.doc.Apply(edit); err != nil {
+		return p.rollback(step, err)
+	}
+	if err := p.sink.Apply(edit, p.doc.Text()); err != nil {
+		return p.rollback(step, err)
+	}
+
+	p.mu.Lock()
+	p.step = step + 1
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Apply the pending seek, rebuilding the document from before if seeking backward.
+// Same as advance, the step is updated only after the sink succeeded, and the sink is called outside the lock.
+func (p *Player) seek() (int, error) {
+	p.mu.Lock()
+	step, current := p.seekTo, p.step
+	p.seekTo = -1
+	p.mu.Unlock()
+
+	from := current
+	if step < current {
+		p.doc = vscode.NewDocument(p.before)
+		from = 0
+	}
+	for i := from; i < step; i++ {
+		if err := p.doc.Apply(p.edits[i]); err != nil {
+			return step, p.rollback(current, err)
+		}
+	}
+	if err := p.sink.Reset(p.doc.Text()); err != nil {
+		return step, p.rollback(current, err)
+	}
+
+	p.mu.Lock()
+	p.step = step
+	p.mu.Unlock()
+
+	return step, nil
+}
+
+// Rebuild the document at step after a failure, as the failed step may have applied the edit partially, and return err.
+// The document is only touched by the playback loop, so it needs no lock.
+func (p *Player) rollback(step int, err error) error {
+	doc := vscode.NewDocument(p.before)
+	for _, e := range p.edits[:step] {
+		if applyErr := doc.Apply(e); applyErr != nil {
+			return err // edits before step were applied once, so this never happens
+		}
+	}
+	p.doc = doc
+	return err
+}
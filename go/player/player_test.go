@@ -0,0 +1,230 @@
+package player_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/richardimaoka/typing-animation/go/player"
+	"github.com/richardimaoka/typing-animation/go/sink"
+)
+
+// Texts of the recorded frames
+func texts(r *sink.Recorder) []string {
+	var result []string
+	for _, f := range r.Frames() {
+		result = append(result, f.Text)
+	}
+	return result
+}
+
+func last(r *sink.Recorder) string {
+	frames := r.Frames()
+	return frames[len(frames)-1].Text
+}
+
+// Typing "abc" char by char
+func typing() []vscode.Edit {
+	return []vscode.Edit{
+		vscode.EditInsert{NewText: "a", Position: vscode.Position{Line: 0, Character: 0}},
+		vscode.EditInsert{NewText: "b", Position: vscode.Position{Line: 0, Character: 1}},
+		vscode.EditInsert{NewText: "c", Position: vscode.Position{Line: 0, Character: 2}},
+	}
+}
+
+func TestPlay(t *testing.T) {
+	r := &sink.Recorder{}
+	p := player.New("", typing(), r, player.Options{Interval: time.Millisecond})
+
+	var events []player.Event
+	done := make(chan struct{})
+	go func() {
+		for e := range p.Events() {
+			events = append(events, e)
+		}
+		close(done)
+	}()
+
+	if err := p.Play(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	<-done
+
+	if d := cmp.Diff([]string{"", "a", "ab", "abc"}, texts(r)); d != "" {
+		t.Errorf("frames: %s", d)
+	}
+	expectedEvents := []player.Event{{Step: 1, Total: 3}, {Step: 2, Total: 3}, {Step: 3, Total: 3}}
+	if d := cmp.Diff(expectedEvents, events); d != "" {
+		t.Errorf("events: %s", d)
+	}
+}
+
+func TestPauseSeekResume(t *testing.T) {
+	r := &sink.Recorder{}
+	p := player.New("", typing(), r, player.Options{Interval: time.Millisecond, Paused: true})
+
+	result := make(chan error)
+	go func() { result <- p.Play(context.Background()) }()
+
+	// Seek while paused shows the frame, but does not continue
+	if err := p.Seek(2); err != nil {
+		t.Fatal(err)
+	}
+	if e := <-p.Events(); e.Step != 2 {
+		t.Fatalf("expected step = 2, but got %+v", e)
+	}
+	if last(r) != "ab" {
+		t.Errorf("expected 'ab', but got '%s'", last(r))
+	}
+
+	// Seek backward
+	if err := p.Seek(1); err != nil {
+		t.Fatal(err)
+	}
+	if e := <-p.Events(); e.Step != 1 {
+		t.Fatalf("expected step = 1, but got %+v", e)
+	}
+	if last(r) != "a" {
+		t.Errorf("expected 'a', but got '%s'", last(r))
+	}
+
+	p.Resume()
+	for range p.Events() {
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if last(r) != "abc" {
+		t.Errorf("expected 'abc', but got '%s'", last(r))
+	}
+
+	if err := p.Seek(4); err == nil {
+		t.Errorf("Expected error: but succeeded to seek out of range")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	r := &sink.Recorder{}
+	p := player.New("", typing(), r, player.Options{Interval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() { result <- p.Play(ctx) }()
+	cancel()
+
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, but got %v", err)
+	}
+	if p.Step() != 0 {
+		t.Errorf("expected step = 0, but got %d", p.Step())
+	}
+}
+
+func TestError(t *testing.T) {
+	r := &sink.Recorder{}
+	edits := append(typing(), vscode.EditInsert{NewText: "x", Position: vscode.Position{Line: 5, Character: 0}})
+	p := player.New("", edits, r, player.Options{Interval: time.Millisecond, Speed: 10})
+
+	var last player.Event
+	done := make(chan struct{})
+	go func() {
+		for e := range p.Events() {
+			last = e
+		}
+		close(done)
+	}()
+
+	err := p.Play(context.Background())
+	<-done
+
+	var lineErr *vscode.ErrLineOutOfRange
+	if !errors.As(err, &lineErr) {
+		t.Fatalf("expected *ErrLineOutOfRange, but got %v", err)
+	}
+	if last.Err == nil || last.Step != 3 {
+		t.Errorf("expected the last event to be the error at step = 3, but got %+v", last)
+	}
+}
+
+// Fails Apply at the given step, and blocks Apply while block is not closed, notifying entered
+type flakySink struct {
+	sink.Recorder
+	failAt  int
+	applied int
+	block   chan struct{}
+	entered chan struct{}
+}
+
+func (f *flakySink) Apply(edit vscode.Edit, text string) error {
+	if f.block != nil {
+		select {
+		case f.entered <- struct{}{}:
+		default:
+		}
+		<-f.block
+	}
+	f.applied++
+	if f.applied == f.failAt {
+		return errors.New("sink failed")
+	}
+	return f.Recorder.Apply(edit, text)
+}
+
+func TestSinkError(t *testing.T) {
+	s := &flakySink{failAt: 2}
+	p := player.New("", typing(), s, player.Options{Interval: time.Millisecond})
+	go func() {
+		for range p.Events() {
+		}
+	}()
+
+	if err := p.Play(context.Background()); err == nil {
+		t.Fatalf("Expected error: but succeeded")
+	}
+
+	// the failed step is not counted as done
+	if p.Step() != 1 {
+		t.Errorf("expected step = 1, but got %d", p.Step())
+	}
+	if d := cmp.Diff([]string{"", "a"}, texts(&s.Recorder)); d != "" {
+		t.Errorf("frames: %s", d)
+	}
+}
+
+func TestPauseWhileSinkIsSlow(t *testing.T) {
+	s := &flakySink{block: make(chan struct{}), entered: make(chan struct{}, 1)}
+	p := player.New("", typing(), s, player.Options{Interval: time.Millisecond})
+	go func() {
+		for range p.Events() {
+		}
+	}()
+
+	result := make(chan error)
+	go func() { result <- p.Play(context.Background()) }()
+
+	// Pause and Seek return while the sink is blocked in Apply
+	<-s.entered
+	paused := make(chan struct{})
+	go func() {
+		p.Pause()
+		p.Seek(3)
+		close(paused)
+	}()
+	select {
+	case <-paused:
+	case <-time.After(time.Second):
+		t.Fatal("Pause and Seek blocked by the slow sink")
+	}
+
+	close(s.block)
+	p.Resume()
+	if err := <-result; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if last(&s.Recorder) != "abc" {
+		t.Errorf("expected 'abc', but got '%s'", last(&s.Recorder))
+	}
+}
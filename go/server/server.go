@@ -2,15 +2,22 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/richardimaoka/typing-animation/go/diff"
 	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
 	"github.com/richardimaoka/typing-animation/go/gitpkg"
+	"github.com/richardimaoka/typing-animation/go/language"
 )
 
+// Limits on the files to animate, which can be changed before Run.
+// Files beyond the limits get a typed status by writeFileStatusJson, instead of edits.
+var FileLimits = gitpkg.DefaultLimits()
+
 func writeErrorJson(w http.ResponseWriter, statusCode int, err error) {
 	body := struct {
 		Status  string `json:"status"`
@@ -29,6 +36,66 @@ func writeErrorJson(w http.ResponseWriter, statusCode int, err error) {
 	}
 }
 
+// Typed status if the file cannot be animated, i.e. binary, too large, or not UTF-8, or nil for other errors
+func fileStatus(err error) *FileStatus {
+	var binary *gitpkg.ErrBinaryFile
+	var tooLarge *gitpkg.ErrFileTooLarge
+	var notUTF8 *gitpkg.ErrNotUTF8
+	switch {
+	case errors.As(err, &binary):
+		return &FileStatus{Status: "binary", Message: binary.Error()}
+	case errors.As(err, &tooLarge):
+		return &FileStatus{
+			Status:   "tooLarge",
+			Message:  tooLarge.Error(),
+			Size:     tooLarge.Size,
+			Lines:    tooLarge.Lines,
+			MaxBytes: tooLarge.Limits.MaxBytes,
+			MaxLines: tooLarge.Limits.MaxLines,
+		}
+	case errors.As(err, &notUTF8):
+		return &FileStatus{Status: "notUtf8", Message: notUTF8.Error(), Encoding: notUTF8.Encoding}
+	default:
+		return nil
+	}
+}
+
+// Write the typed status if the file cannot be animated, and return true.
+// Return false for other errors, which the caller handles.
+func writeFileStatusJson(w http.ResponseWriter, err error) bool {
+	body := fileStatus(err)
+	if body == nil {
+		return false
+	}
+	log.Printf("Returning file status = %s, %s", body.Status, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Writing HTTP file status response failed, %s", err)
+	}
+	return true
+}
+
+// Diff options by the diff query parameter, "myers", "patience" or "histogram",
+// which diffs line by line with the algorithm, and refines the changed lines char by char.
+// Empty diffParam is diff.DefaultOptions().
+func diffOptions(diffParam string) (diff.Options, error) {
+	opts := diff.DefaultOptions()
+	if diffParam == "" {
+		return opts, nil
+	}
+
+	algorithm, err := diff.AlgorithmByName(diffParam)
+	if err != nil {
+		return opts, err
+	}
+	opts.LineMode = true
+	opts.Refine = diff.RefineChar
+	opts.Algorithm = algorithm
+	return opts, nil
+}
+
 func HandleGET_Repo(w http.ResponseWriter, r *http.Request) {
 	// Check path parameters
 	orgname := r.PathValue("orgname")
@@ -177,6 +244,39 @@ func HandleSingleFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check query parameters
+	split := r.URL.Query().Get("split")
+	var splitStrategy vscode.SplitStrategy
+	if split != "" {
+		var err error
+		splitStrategy, err = vscode.SplitStrategyByName(split)
+		if err != nil {
+			writeErrorJson(w, http.StatusBadRequest, fmt.Errorf("split = '%s' must be one of char, word, line or token", split))
+			return
+		}
+	}
+	hintsParam := r.URL.Query().Get("hints")
+	if hintsParam != "" && hintsParam != "1" {
+		writeErrorJson(w, http.StatusBadRequest, fmt.Errorf("hints = '%s' must be 1, or empty for no hints", hintsParam))
+		return
+	}
+	decorationsParam := r.URL.Query().Get("decorations")
+	if decorationsParam != "" && decorationsParam != "step" {
+		writeErrorJson(w, http.StatusBadRequest, fmt.Errorf("decorations = '%s' must be step, or empty for the transition only", decorationsParam))
+		return
+	}
+	diffParam := r.URL.Query().Get("diff")
+	diffOpts, err := diffOptions(diffParam)
+	if err != nil {
+		writeErrorJson(w, http.StatusBadRequest, fmt.Errorf("diff = '%s' must be one of myers, patience or histogram", diffParam))
+		return
+	}
+	languageParam := r.URL.Query().Get("language")
+	if languageParam != "" && !language.IsKnown(languageParam) {
+		writeErrorJson(w, http.StatusBadRequest, fmt.Errorf("language = '%s' is not a Monaco language id", languageParam))
+		return
+	}
+
 	// Path parameter checks passed
 	log.Printf("GET /repos/%s/%s/files/%s called", orgname, reponame, filepath)
 
@@ -217,6 +317,9 @@ func HandleSingleFile(w http.ResponseWriter, r *http.Request) {
 	// Get edits and current contents
 	var currentContents string
 	var edits []monaco.SingleEditOperation
+	var decorations *diff.Decorations
+	var stepDecorations []diff.Decorations
+	var nextStatus *FileStatus
 	commitHash := r.URL.Query().Get("commit")
 	if commitHash != "" {
 		var nextCommit string
@@ -228,49 +331,242 @@ func HandleSingleFile(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if nextCommit != "" {
-			currentFile, err := gitpkg.FileInCommit(orgname, reponame, filepath, commitHash)
+			currentContents, err = gitpkg.RepoFileContentsWithLimits(orgname, reponame, filepath, commitHash, FileLimits)
 			if err != nil {
+				if writeFileStatusJson(w, err) {
+					return
+				}
 				log.Printf("Error upon getting git file in the repo, %s", err)
 				writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
 				return
 			}
-			currentContents, err = currentFile.Contents()
-			if err != nil {
+
+			nextContents, err := gitpkg.RepoFileContentsWithLimits(orgname, reponame, filepath, nextCommit, FileLimits)
+			if status := fileStatus(err); status != nil {
+				// Keep the current file, only without the edits to the next commit
+				log.Printf("No edits to the next commit, file status = %s, %s", status.Status, err)
+				nextStatus = status
+			} else if err != nil {
 				log.Printf("Error upon getting git file in the repo, %s", err)
 				writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
 				return
+			} else {
+				// Split edits server-side, so that the frontend can animate them step by step, and optionally add hints to each step
+				opts := diffOpts
+				opts.MonacoSplit = splitStrategy
+				opts.MonacoHints = hintsParam == "1"
+				edits, err = diff.CalcMonacoEditsWithOptions(currentContents, nextContents, opts)
+				if err != nil {
+					log.Printf("Error upon getting git file in the repo, %s", err)
+					writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
+					return
+				}
+
+				// Highlight the changed regions of the whole transition, and optionally of each step
+				transition := diff.CalcDecorations(currentContents, nextContents, diffOpts)
+				decorations = &transition
+				if decorationsParam == "step" {
+					stepDecorations = diff.StepDecorations(edits)
+				}
 			}
+		}
+	}
 
-			nextFile, err := gitpkg.FileInCommit(orgname, reponame, filepath, nextCommit)
-			if err != nil {
-				log.Printf("Error upon getting git file in the repo, %s", err)
-				writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
-				return
+	// Language of the file, unless overridden by the client
+	lang := languageParam
+	if lang == "" {
+		lang = language.Detect(filepath, currentContents)
+	}
+
+	// Success
+	body := struct {
+		Orgname         string                       `json:"orgname"`
+		Repo            string                       `json:"repo"`
+		Commits         []CommitData                 `json:"commits"`
+		Contents        string                       `json:"contents"`
+		Language        string                       `json:"language"`
+		Edits           []monaco.SingleEditOperation `json:"edits"`
+		Decorations     *diff.Decorations            `json:"decorations,omitempty"`
+		StepDecorations []diff.Decorations           `json:"stepDecorations,omitempty"`
+		NextStatus      *FileStatus                  `json:"nextStatus,omitempty"` // set if the file in the next commit cannot be animated, without edits
+	}{orgname, reponame, commitDataSlice, currentContents, lang, edits, decorations, stepDecorations, nextStatus}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(body)
+	if err != nil {
+		log.Printf("Error upon encoding body, %+v, to json, %s", body, err)
+		writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
+		return
+	}
+}
+
+// File contents in the commit checked with FileLimits, or empty if the file doesn't exist in the commit.
+// CommitsForFile includes the commit deleting the file, which has no file to read.
+func fileContentsOrEmpty(orgname, reponame, filepath, commitHash string) (string, error) {
+	contents, err := gitpkg.RepoFileContentsWithLimits(orgname, reponame, filepath, commitHash, FileLimits)
+	if errors.Is(err, gitpkg.ErrFileNotFound) {
+		return "", nil
+	}
+
+	return contents, err
+}
+
+// Edits from contents to the adjacent commit's contents by opts with decorations of the whole transition,
+// and decorations for each of the edits if step, same as diff.StepDecorations for Monaco operations
+func transitionEdits(contents, adjacentContents string, opts diff.Options, step bool) ([]vscode.Edit, diff.Decorations, []diff.Decorations, error) {
+	edits, err := diff.CalcEditsWithOptions(contents, adjacentContents, opts)
+	if err != nil {
+		return nil, diff.Decorations{}, nil, err
+	}
+	decorations := diff.CalcDecorations(contents, adjacentContents, opts)
+	if !step {
+		return edits, decorations, nil, nil
+	}
+
+	var ops []monaco.SingleEditOperation
+	for _, e := range edits {
+		op, err := vscode.EditToMonaco(e)
+		if err != nil {
+			return nil, diff.Decorations{}, nil, err
+		}
+		ops = append(ops, op)
+	}
+
+	return edits, decorations, diff.StepDecorations(ops), nil
+}
+
+// Versioned API of HandleSingleFile, returning FileData with edits in the VS Code style,
+// which are consumed by both the VS Code extension and Monaco clients
+func HandleFileDataV1(w http.ResponseWriter, r *http.Request) {
+	// Check path parameters
+	orgname := r.PathValue("orgname")
+	reponame := r.PathValue("reponame")
+	filepath := r.PathValue("filepath")
+	if orgname == "" || reponame == "" || filepath == "" {
+		writeErrorJson(
+			w,
+			http.StatusBadRequest,
+			fmt.Errorf("orgname = '%s', reponame = '%s', filepath = '%s', but neither allows an empty value", orgname, reponame, filepath),
+		)
+		return
+	}
+
+	// Check query parameters
+	decorationsParam := r.URL.Query().Get("decorations")
+	if decorationsParam != "" && decorationsParam != "step" {
+		writeErrorJson(w, http.StatusBadRequest, fmt.Errorf("decorations = '%s' must be step, or empty for the transition only", decorationsParam))
+		return
+	}
+	diffParam := r.URL.Query().Get("diff")
+	diffOpts, err := diffOptions(diffParam)
+	if err != nil {
+		writeErrorJson(w, http.StatusBadRequest, fmt.Errorf("diff = '%s' must be one of myers, patience or histogram", diffParam))
+		return
+	}
+	languageParam := r.URL.Query().Get("language")
+	if languageParam != "" && !language.IsKnown(languageParam) {
+		writeErrorJson(w, http.StatusBadRequest, fmt.Errorf("language = '%s' is not a Monaco language id", languageParam))
+		return
+	}
+
+	// Path parameter checks passed
+	log.Printf("GET /%s/%s/v1/files/%s called", orgname, reponame, filepath)
+
+	// Get commits, from the newest to the oldest
+	commits, err := gitpkg.CommitsForFile(orgname, reponame, filepath)
+	if err != nil {
+		log.Printf("Error upon getting git file in the repo, %s", err)
+		writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
+		return
+	}
+	if len(commits) == 0 {
+		writeErrorJson(w, http.StatusNotFound, fmt.Errorf("no commit found for filepath = '%s'", filepath))
+		return
+	}
+
+	// Find the commit, or the oldest commit if not specified
+	index := len(commits) - 1
+	if commitHash := r.URL.Query().Get("commit"); commitHash != "" {
+		index = -1
+		for i, c := range commits {
+			hash := c.Hash.String()
+			if commitHash == hash || commitHash == hash[:7] {
+				index = i
+				break
 			}
-			nextContents, err := nextFile.Contents()
+		}
+		if index == -1 {
+			writeErrorJson(w, http.StatusNotFound, fmt.Errorf("commit = '%s' not found for filepath = '%s'", commitHash, filepath))
+			return
+		}
+	}
+	commitHash := commits[index].Hash.String()
+
+	contents, err := fileContentsOrEmpty(orgname, reponame, filepath, commitHash)
+	if err != nil {
+		if writeFileStatusJson(w, err) {
+			return
+		}
+		log.Printf("Error upon getting git file in the repo, %s", err)
+		writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
+		return
+	}
+
+	body := FileData{
+		CommitHash: commitHash,
+		FilePath:   filepath,
+		Contents:   contents,
+		Language:   languageParam,
+	}
+	if body.Language == "" {
+		body.Language = language.Detect(filepath, contents)
+	}
+
+	// Transitions to the adjacent commits, whose edits are calculated from the contents already read,
+	// to read each file only once
+	if index > 0 {
+		nextHash := commits[index-1].Hash.String()
+		nextContents, err := fileContentsOrEmpty(orgname, reponame, filepath, nextHash)
+		if status := fileStatus(err); status != nil {
+			// Keep the current file, only without the edits to the next commit
+			log.Printf("No edits to the next commit, file status = %s, %s", status.Status, err)
+			body.Next = &NextTransition{CommitHash: nextHash, Status: status}
+		} else if err != nil {
+			log.Printf("Error upon getting git file in the next commit, %s", err)
+			writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
+			return
+		} else {
+			edits, decorations, steps, err := transitionEdits(contents, nextContents, diffOpts, decorationsParam == "step")
 			if err != nil {
-				log.Printf("Error upon getting git file in the repo, %s", err)
+				log.Printf("Error upon calculating edits to the next commit, %s", err)
 				writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
 				return
 			}
-
-			edits, err = diff.CalcMonacoEdits(currentContents, nextContents)
+			body.Next = &NextTransition{CommitHash: nextHash, Edits: edits, Decorations: decorations, StepDecorations: steps}
+		}
+	}
+	if index < len(commits)-1 {
+		prevHash := commits[index+1].Hash.String()
+		prevContents, err := fileContentsOrEmpty(orgname, reponame, filepath, prevHash)
+		if status := fileStatus(err); status != nil {
+			// Keep the current file, only without the edits to the previous commit
+			log.Printf("No edits to the previous commit, file status = %s, %s", status.Status, err)
+			body.Prev = &PrevTransition{CommitHash: prevHash, Status: status}
+		} else if err != nil {
+			log.Printf("Error upon getting git file in the previous commit, %s", err)
+			writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
+			return
+		} else {
+			edits, decorations, steps, err := transitionEdits(contents, prevContents, diffOpts, decorationsParam == "step")
 			if err != nil {
-				log.Printf("Error upon getting git file in the repo, %s", err)
+				log.Printf("Error upon calculating edits to the previous commit, %s", err)
 				writeErrorJson(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
 				return
 			}
+			body.Prev = &PrevTransition{CommitHash: prevHash, Edits: edits, Decorations: decorations, StepDecorations: steps}
 		}
 	}
 
 	// Success
-	body := struct {
-		Orgname  string                       `json:"orgname"`
-		Repo     string                       `json:"repo"`
-		Commits  []CommitData                 `json:"commits"`
-		Contents string                       `json:"contents"`
-		Edits    []monaco.SingleEditOperation `json:"edits"`
-	}{orgname, reponame, commitDataSlice, currentContents, edits}
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(body)
 	if err != nil {
@@ -337,6 +633,7 @@ func Run() {
 	mux.HandleFunc("GET /{orgname}/{reponame}/files", HandleRepoFiles)
 	mux.HandleFunc("GET /{orgname}/{reponame}/branches", HandleRepoBranches)
 	mux.HandleFunc("GET /{orgname}/{reponame}/files/{filepath...}", HandleSingleFile)
+	mux.HandleFunc("GET /{orgname}/{reponame}/v1/files/{filepath...}", HandleFileDataV1)
 
 	// mux.HandleFunc("GET /repos/{orgname}/{reponame}/", HandleRepoFiles)
 	// mux.HandleFunc("GET /repos/{orgname}/{reponame}/branches", HandleRepoFiles)
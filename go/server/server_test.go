@@ -0,0 +1,323 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/monaco"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/richardimaoka/typing-animation/go/server"
+)
+
+// Create a local repo where gitpkg looks for it, committing each of files in order,
+// where nil contents delete the file. Returns the org name and the commit hashes.
+func createRepo(t *testing.T, reponame, filename string, files []*string) (string, []string) {
+	if err := os.MkdirAll("/tmp/github.com", 0755); err != nil {
+		t.Fatal(err)
+	}
+	orgDir, err := os.MkdirTemp("/tmp/github.com", "server-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(orgDir) })
+
+	repoDir := filepath.Join(orgDir, reponame)
+	repo, err := git.PlainInit(repoDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hashes []string
+	for i, contents := range files {
+		if contents == nil {
+			if _, err := wt.Remove(filename); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			if err := os.WriteFile(filepath.Join(repoDir, filename), []byte(*contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := wt.Add(filename); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// go-git sees the worktree as clean after Remove, though the index has the file deleted
+		signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(int64(i), 0)}
+		hash, err := wt.Commit("commit", &git.CommitOptions{Author: signature, AllowEmptyCommits: contents == nil})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash.String())
+	}
+
+	return filepath.Base(orgDir), hashes
+}
+
+func getFileData(t *testing.T, orgname, reponame, filename, query string) server.FileData {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{orgname}/{reponame}/v1/files/{filepath...}", server.HandleFileDataV1)
+
+	r := httptest.NewRequest("GET", "/"+orgname+"/"+reponame+"/v1/files/"+filename+"?"+query, nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status = 200, but got %d, %s", w.Code, w.Body.String())
+	}
+
+	var data server.FileData
+	if err := json.Unmarshal(w.Body.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestFileDataV1DeletedFile(t *testing.T) {
+	v0, v1, v2 := "package main\n", "package main\n\nfunc main() {}\n", "package main\n\nfunc main() {\n\tprintln()\n}\n"
+	// CommitsForFile skips the root commit, and includes the commit deleting the file
+	orgname, hashes := createRepo(t, "repo", "main.go", []*string{&v0, &v1, &v2, nil})
+	added, updated, deleted := hashes[1], hashes[2], hashes[3]
+
+	cases := map[string]struct {
+		commit       string
+		contents     string
+		prev         string
+		prevContents string
+		next         string // empty if no next transition
+	}{
+		"before deleted": {updated, v2, added, v1, deleted},
+		"deleted":        {deleted, "", updated, v2, ""},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			data := getFileData(t, orgname, "repo", "main.go", "commit="+c.commit)
+			if data.Contents != c.contents {
+				t.Errorf("expected contents = %q, but got %q", c.contents, data.Contents)
+			}
+
+			if data.Prev == nil || data.Prev.CommitHash != c.prev {
+				t.Fatalf("expected prev commit = %s, but got %+v", c.prev, data.Prev)
+			}
+			prev, err := vscode.ApplyEdits(data.Contents, data.Prev.Edits)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if d := cmp.Diff(c.prevContents, prev); d != "" {
+				t.Errorf("prev: %s", d)
+			}
+
+			if c.next == "" {
+				if data.Next != nil {
+					t.Errorf("expected no next transition, but got %+v", data.Next)
+				}
+				return
+			}
+			if data.Next == nil || data.Next.CommitHash != c.next {
+				t.Fatalf("expected next commit = %s, but got %+v", c.next, data.Next)
+			}
+			next, err := vscode.ApplyEdits(data.Contents, data.Next.Edits)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if next != "" {
+				t.Errorf("expected the deleted file to be empty, but got %q", next)
+			}
+		})
+	}
+}
+
+func TestFileDataV1StepDecorations(t *testing.T) {
+	v0, v1, v2 := "a\n", "a\nb\n", "c\nb\nd\n"
+	orgname, hashes := createRepo(t, "repo", "file.txt", []*string{&v0, &v1, &v2})
+
+	cases := map[string]struct {
+		query string
+		step  bool
+	}{
+		"transition only": {"commit=" + hashes[1], false},
+		"step":            {"commit=" + hashes[1] + "&decorations=step", true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			data := getFileData(t, orgname, "repo", "file.txt", c.query)
+			if data.Next == nil || len(data.Next.Decorations.After) == 0 {
+				t.Fatalf("expected next transition with decorations, but got %+v", data.Next)
+			}
+
+			if !c.step {
+				if data.Next.StepDecorations != nil {
+					t.Errorf("expected no step decorations, but got %+v", data.Next.StepDecorations)
+				}
+				return
+			}
+			if len(data.Next.StepDecorations) != len(data.Next.Edits) {
+				t.Errorf("expected %d step decorations, one per edit, but got %d", len(data.Next.Edits), len(data.Next.StepDecorations))
+			}
+		})
+	}
+}
+
+// The JSON keys of transitions are kept from the original FileData
+func TestFileDataV1Keys(t *testing.T) {
+	v0, v1, v2 := "a\n", "b\n", "c\n"
+	orgname, hashes := createRepo(t, "repo", "file.txt", []*string{&v0, &v1, &v2})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{orgname}/{reponame}/v1/files/{filepath...}", server.HandleFileDataV1)
+	r := httptest.NewRequest("GET", "/"+orgname+"/repo/v1/files/file.txt?commit="+hashes[2], nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"commitHash", "filePath", "contents", "nextTransaction", "prevTransaction"} {
+		if _, ok := body[key]; !ok {
+			t.Errorf("expected key = %s in %s", key, w.Body.String())
+		}
+	}
+}
+
+func TestSingleFileHints(t *testing.T) {
+	v0, v1, v2 := "a\n", "a\nb\n", "c\nb\nd\n"
+	orgname, hashes := createRepo(t, "repo", "file.txt", []*string{&v0, &v1, &v2})
+
+	cases := map[string]struct {
+		query string
+		hints bool
+	}{
+		"no hints by default": {"commit=" + hashes[2], false},
+		"hints":               {"commit=" + hashes[2] + "&hints=1", true},
+		"hints and split":     {"commit=" + hashes[2] + "&hints=1&split=char", true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /{orgname}/{reponame}/files/{filepath...}", server.HandleSingleFile)
+			r := httptest.NewRequest("GET", "/"+orgname+"/repo/files/file.txt?"+c.query, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status = 200, but got %d, %s", w.Code, w.Body.String())
+			}
+
+			var body struct {
+				Edits []monaco.SingleEditOperation `json:"edits"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Edits) == 0 {
+				t.Fatalf("expected edits, but got none")
+			}
+			for i, op := range body.Edits {
+				if (op.Cursor != nil) != c.hints {
+					t.Errorf("expected hints = %t, but got edits[%d] = %+v", c.hints, i, op)
+				}
+			}
+		})
+	}
+}
+
+// The current file is still returned, when the file in the adjacent commit cannot be animated
+func TestAdjacentBinaryFile(t *testing.T) {
+	v0, v1, v2 := "a\n", "\x89PNG\x00\x00", "b\n"
+	orgname, hashes := createRepo(t, "repo", "file.txt", []*string{&v0, &v1, &v2})
+
+	t.Run("file data v1", func(t *testing.T) {
+		data := getFileData(t, orgname, "repo", "file.txt", "commit="+hashes[2])
+		if data.Contents != v2 {
+			t.Errorf("expected contents = %q, but got %q", v2, data.Contents)
+		}
+		if data.Prev == nil || data.Prev.Status == nil || data.Prev.Status.Status != "binary" {
+			t.Fatalf("expected prev transition with status = binary, but got %+v", data.Prev)
+		}
+		if len(data.Prev.Edits) != 0 {
+			t.Errorf("expected no edits, but got %+v", data.Prev.Edits)
+		}
+	})
+
+	t.Run("single file", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /{orgname}/{reponame}/files/{filepath...}", server.HandleSingleFile)
+		r := httptest.NewRequest("GET", "/"+orgname+"/repo/files/file.txt?commit="+hashes[2], nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status = 200, but got %d, %s", w.Code, w.Body.String())
+		}
+
+		var body struct {
+			Contents   string             `json:"contents"`
+			NextStatus *server.FileStatus `json:"nextStatus"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Contents != v2 {
+			t.Errorf("expected contents = %q, but got %q", v2, body.Contents)
+		}
+		if body.NextStatus == nil || body.NextStatus.Status != "binary" {
+			t.Errorf("expected next status = binary, but got %+v", body.NextStatus)
+		}
+	})
+}
+
+func TestDiffParam(t *testing.T) {
+	v0, v1, v2 := "a\n", "func a() {\n\tx := 1\n}\n\nfunc b() {\n\ty := 2\n}\n", "func b() {\n\ty := 2\n}\n\nfunc a() {\n\tx := 1\n}\n"
+	orgname, hashes := createRepo(t, "repo", "main.go", []*string{&v0, &v1, &v2})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{orgname}/{reponame}/files/{filepath...}", server.HandleSingleFile)
+	mux.HandleFunc("GET /{orgname}/{reponame}/v1/files/{filepath...}", server.HandleFileDataV1)
+
+	cases := map[string]struct {
+		path   string
+		diff   string
+		status int
+	}{
+		"single file, patience":      {"/files/main.go", "patience", http.StatusOK},
+		"single file, histogram":     {"/files/main.go", "histogram", http.StatusOK},
+		"single file, ERROR unknown": {"/files/main.go", "minimal", http.StatusBadRequest},
+		"v1, patience":               {"/v1/files/main.go", "patience", http.StatusOK},
+		"v1, myers":                  {"/v1/files/main.go", "myers", http.StatusOK},
+		"v1, ERROR unknown":          {"/v1/files/main.go", "minimal", http.StatusBadRequest},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/"+orgname+"/repo"+c.path+"?commit="+hashes[2]+"&diff="+c.diff, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != c.status {
+				t.Errorf("expected status = %d, but got %d, %s", c.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The edits by the chosen algorithm still produce the file in the adjacent commit
+	data := getFileData(t, orgname, "repo", "main.go", "commit="+hashes[2]+"&diff=patience")
+	prev, err := vscode.ApplyEdits(data.Contents, data.Prev.Edits)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d := cmp.Diff(v1, prev); d != "" {
+		t.Errorf("%s", d)
+	}
+}
@@ -1,38 +1,50 @@
 package server
 
-type Position struct {
-	Line      int `json:"line"`      //The zero-based line value.
-	Character int `json:"character"` //The zero-based character value.
-}
-
-type Range struct {
-	Start Position `json:"start"`
-	End   Position `json:"end"`
-}
+import (
+	"github.com/richardimaoka/typing-animation/go/diff"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
 
-type EditInsert struct {
-	NewText  string   `json:"newText"`
-	Position Position `json:"position"`
+// Status of a file which cannot be animated
+type FileStatus struct {
+	Status   string `json:"status"` // "binary", "tooLarge", or "notUtf8"
+	Message  string `json:"message"`
+	Encoding string `json:"encoding,omitempty"` // detected encoding if notUtf8
+	Size     int64  `json:"size,omitempty"`     // size in bytes if tooLarge
+	Lines    int    `json:"lines,omitempty"`    // number of lines if tooLarge by lines
+	MaxBytes int64  `json:"maxBytes,omitempty"`
+	MaxLines int    `json:"maxLines,omitempty"`
 }
 
-type EditDelete struct {
-	DeleteRange Range `json:"deleteRange"`
-}
-
-type Edit struct {
-	EditType string `json:"editType"`
-	// Either of Insert or Delete must be non-nil, dependent on EditType
-	Insert *EditInsert `json:"insert"`
-	Delete *EditDelete `json:"delete"`
+// Edits turning FileData.Contents into the file in the next, i.e. newer, commit
+type NextTransition struct {
+	CommitHash string       `json:"commitHash"`
+	Edits      vscode.Edits `json:"edits"`
+	// Highlights of the changed regions, Before on FileData.Contents, and After on the file in the transitioned commit
+	Decorations diff.Decorations `json:"decorations"`
+	// Highlights of each of Edits, only with the decorations=step query parameter
+	StepDecorations []diff.Decorations `json:"stepDecorations,omitempty"`
+	// Set if the file in the transitioned commit cannot be animated, where Edits and Decorations are empty
+	Status *FileStatus `json:"status,omitempty"`
 }
 
-type NextTransition struct {
-	Edits []Edit `json:"edits"`
+// Edits turning FileData.Contents back into the file in the previous, i.e. older, commit
+type PrevTransition struct {
+	CommitHash string       `json:"commitHash"`
+	Edits      vscode.Edits `json:"edits"`
+	// Same as NextTransition.Decorations, but After is on the file in the previous commit
+	Decorations diff.Decorations `json:"decorations"`
+	// Same as NextTransition.StepDecorations
+	StepDecorations []diff.Decorations `json:"stepDecorations,omitempty"`
+	// Same as NextTransition.Status
+	Status *FileStatus `json:"status,omitempty"`
 }
 
 type FileData struct {
-	CommitHash string         `json:"commitHash"`
-	FilePath   string         `json:"filePath"`
-	Contents   string         `json:"contents"`
-	Next       NextTransition `json:"nextTransaction"`
+	CommitHash string          `json:"commitHash"`
+	FilePath   string          `json:"filePath"`
+	Contents   string          `json:"contents"`
+	Language   string          `json:"language"`        // Monaco language id, detected or overridden by the language query parameter
+	Prev       *PrevTransition `json:"prevTransaction"` // nil for the oldest commit of the file
+	Next       *NextTransition `json:"nextTransaction"` // nil for the newest commit of the file
 }
@@ -0,0 +1,36 @@
+package sink
+
+import (
+	"fmt"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Sink keeping an in-memory document, which applies each edit incrementally
+type DocumentSink struct {
+	doc *vscode.Document
+}
+
+func Document() *DocumentSink {
+	return &DocumentSink{doc: vscode.NewDocument("")}
+}
+
+func (d *DocumentSink) Document() *vscode.Document {
+	return d.doc
+}
+
+func (d *DocumentSink) Reset(text string) error {
+	d.doc = vscode.NewDocument(text)
+	return nil
+}
+
+// The edit is applied to the document, and the result is checked against text
+func (d *DocumentSink) Apply(edit vscode.Edit, text string) error {
+	if err := d.doc.Apply(edit); err != nil {
+		return err
+	}
+	if d.doc.Text() != text {
+		return fmt.Errorf("DocumentSink.Apply failed, document diverged from the stream after edit = %+v", edit)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package sink
+
+import "github.com/richardimaoka/typing-animation/go/edit/vscode"
+
+type fileSink struct {
+	filename string
+}
+
+// Write the whole text to the file atomically on every edit, so that editors reloading the file never see a partial write
+func File(filename string) Sink {
+	return fileSink{filename: filename}
+}
+
+func (f fileSink) Reset(text string) error {
+	return vscode.WriteFile(f.filename, text)
+}
+
+func (f fileSink) Apply(edit vscode.Edit, text string) error {
+	return vscode.WriteFile(f.filename, text)
+}
@@ -0,0 +1,39 @@
+package sink
+
+import (
+	"sync"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Recorded frame, where Edit is nil for a reset
+type Frame struct {
+	Edit vscode.Edit
+	Text string
+}
+
+// Sink recording every frame, e.g. to export the animation later
+type Recorder struct {
+	mu     sync.Mutex
+	frames []Frame
+}
+
+func (r *Recorder) Reset(text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = append(r.frames, Frame{Text: text})
+	return nil
+}
+
+func (r *Recorder) Apply(edit vscode.Edit, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = append(r.frames, Frame{Edit: edit, Text: text})
+	return nil
+}
+
+func (r *Recorder) Frames() []Frame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Frame{}, r.frames...)
+}
@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"errors"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// Target of an edit stream, e.g. a file, an in-memory document, a terminal, or a WebSocket client.
+// The same stream can drive multiple sinks at once with Multi.
+type Sink interface {
+	// Replace the whole text, at the beginning of the stream and after a seek
+	Reset(text string) error
+	// Apply an edit, where text is the whole text after the edit, for sinks which cannot apply edits incrementally
+	Apply(edit vscode.Edit, text string) error
+}
+
+type multiSink []Sink
+
+// Fan out the stream to all the sinks.
+// A failing sink doesn't stop the others, and the errors are joined.
+func Multi(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) Reset(text string) error {
+	var errs []error
+	for _, s := range m {
+		errs = append(errs, s.Reset(text))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiSink) Apply(edit vscode.Edit, text string) error {
+	var errs []error
+	for _, s := range m {
+		errs = append(errs, s.Apply(edit, text))
+	}
+	return errors.Join(errs...)
+}
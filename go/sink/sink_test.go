@@ -0,0 +1,125 @@
+package sink_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"github.com/richardimaoka/typing-animation/go/sink"
+	"golang.org/x/net/websocket"
+)
+
+// Drive the sink by the stream: reset to "ab", then insert "c" at the end
+func drive(s sink.Sink) error {
+	edit := vscode.EditInsert{NewText: "c", Position: vscode.Position{Line: 0, Character: 2}}
+	return errors.Join(s.Reset("ab"), s.Apply(edit, "abc"))
+}
+
+// Sink always failing
+type failing struct{}
+
+func (failing) Reset(text string) error                   { return errors.New("reset failed") }
+func (failing) Apply(edit vscode.Edit, text string) error { return errors.New("apply failed") }
+
+func TestMulti(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(filename, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	doc := sink.Document()
+	recorder := &sink.Recorder{}
+	var terminal bytes.Buffer
+
+	err := drive(sink.Multi(sink.File(filename), doc, failing{}, recorder, sink.Terminal(&terminal)))
+	// the failing sink doesn't stop the others
+	if err == nil || !strings.Contains(err.Error(), "reset failed") {
+		t.Errorf("expected the joined error, but got %v", err)
+	}
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "abc" {
+		t.Errorf("file: expected 'abc', but got '%s'", contents)
+	}
+
+	if doc.Document().Text() != "abc" {
+		t.Errorf("document: expected 'abc', but got '%s'", doc.Document().Text())
+	}
+
+	expectedFrames := []sink.Frame{
+		{Text: "ab"},
+		{Edit: vscode.EditInsert{NewText: "c", Position: vscode.Position{Line: 0, Character: 2}}, Text: "abc"},
+	}
+	if d := cmp.Diff(expectedFrames, recorder.Frames()); d != "" {
+		t.Errorf("recorder: %s", d)
+	}
+
+	if !strings.HasSuffix(terminal.String(), "\x1b[H\x1b[2Jabc") {
+		t.Errorf("terminal: expected the screen redrawn with 'abc', but got %q", terminal.String())
+	}
+}
+
+func TestDocumentDiverged(t *testing.T) {
+	doc := sink.Document()
+	if err := doc.Reset("ab"); err != nil {
+		t.Fatal(err)
+	}
+
+	edit := vscode.EditInsert{NewText: "c", Position: vscode.Position{Line: 0, Character: 2}}
+	if err := doc.Apply(edit, "abx"); err == nil {
+		t.Errorf("Expected error: but succeeded with text = '%s'", doc.Document().Text())
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	received := make(chan []sink.Message, 1)
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var messages []sink.Message
+		for i := 0; i < 2; i++ {
+			var m sink.Message
+			if err := websocket.JSON.Receive(conn, &m); err != nil {
+				break
+			}
+			messages = append(messages, m)
+		}
+		received <- messages
+	}))
+	defer server.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := drive(sink.WebSocket(conn)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []sink.Message{
+		{Type: "reset", Text: "ab"},
+		{Type: "edit", Edits: vscode.Edits{vscode.EditInsert{NewText: "c", Position: vscode.Position{Line: 0, Character: 2}}}},
+	}
+	if d := cmp.Diff(expected, <-received); d != "" {
+		t.Errorf("%s", d)
+	}
+}
+
+func TestMessageResetToEmpty(t *testing.T) {
+	encoded, err := json.Marshal(sink.Message{Type: "reset", Text: ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d := cmp.Diff(`{"type":"reset","text":""}`, string(encoded)); d != "" {
+		t.Errorf("%s", d)
+	}
+}
@@ -0,0 +1,29 @@
+package sink
+
+import (
+	"io"
+
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+)
+
+// ANSI escape sequence to move the cursor home and clear the screen
+const clearScreen = "\x1b[H\x1b[2J"
+
+type terminalSink struct {
+	w io.Writer
+}
+
+// Redraw the whole text on a terminal, e.g. os.Stdout, on every edit
+func Terminal(w io.Writer) Sink {
+	return terminalSink{w: w}
+}
+
+func (t terminalSink) Reset(text string) error {
+	_, err := io.WriteString(t.w, clearScreen+text)
+	return err
+}
+
+func (t terminalSink) Apply(edit vscode.Edit, text string) error {
+	_, err := io.WriteString(t.w, clearScreen+text)
+	return err
+}
@@ -0,0 +1,30 @@
+package sink
+
+import (
+	"github.com/richardimaoka/typing-animation/go/edit/vscode"
+	"golang.org/x/net/websocket"
+)
+
+// JSON message sent to the WebSocket client
+type Message struct {
+	Type  string       `json:"type"`            // "reset" or "edit"
+	Edits vscode.Edits `json:"edits,omitempty"` // single edit for "edit", tagged by "editType"
+	Text  string       `json:"text"`            // whole text for "reset", sent even if empty, and empty for "edit"
+}
+
+type webSocketSink struct {
+	conn *websocket.Conn
+}
+
+// Send each edit to the WebSocket client as a JSON Message, so that the client applies edits to its own editor
+func WebSocket(conn *websocket.Conn) Sink {
+	return webSocketSink{conn: conn}
+}
+
+func (s webSocketSink) Reset(text string) error {
+	return websocket.JSON.Send(s.conn, Message{Type: "reset", Text: text})
+}
+
+func (s webSocketSink) Apply(edit vscode.Edit, text string) error {
+	return websocket.JSON.Send(s.conn, Message{Type: "edit", Edits: vscode.Edits{edit}})
+}